@@ -0,0 +1,191 @@
+// Package admin implements the authenticated admin area used to manage
+// guest entries.
+package admin
+
+import (
+	"errors"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/dreamsofcode-io/guestbook/internal/ban"
+	"github.com/dreamsofcode-io/guestbook/internal/config"
+	"github.com/dreamsofcode-io/guestbook/internal/csrf"
+	"github.com/dreamsofcode-io/guestbook/internal/moderation"
+	"github.com/dreamsofcode-io/guestbook/internal/repository"
+)
+
+const pageSize = 50
+
+type Handler struct {
+	logger    *slog.Logger
+	tmpl      *template.Template
+	repo      *repository.Queries
+	moderator *moderation.Moderator
+	bans      *ban.List
+	cfg       *config.Admin
+}
+
+func New(
+	logger *slog.Logger, db *pgxpool.Pool, tmpl *template.Template,
+	cfg *config.Admin,
+) *Handler {
+	return &Handler{
+		logger:    logger,
+		tmpl:      tmpl,
+		repo:      repository.New(db),
+		moderator: moderation.New(db),
+		bans:      ban.New(db),
+		cfg:       cfg,
+	}
+}
+
+type dashboardPage struct {
+	User     User
+	CSRF     string
+	Stats    repository.StatsRow
+	Guests   []repository.Guest
+	Page     int
+	PrevPage int
+	NextPage int
+}
+
+func (h *Handler) Dashboard(w http.ResponseWriter, r *http.Request) {
+	user, _ := UserFromContext(r.Context())
+
+	page, err := strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	stats, err := h.repo.Stats(r.Context())
+	if err != nil {
+		h.logger.Error("failed to get stats", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	// Fetch one extra row to find out whether there is a next page.
+	guests, err := h.repo.FindPage(r.Context(), repository.FindPageParams{
+		Limit:  pageSize + 1,
+		Offset: int32((page - 1) * pageSize),
+	})
+	if err != nil {
+		h.logger.Error("failed to find guests", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	data := dashboardPage{
+		User:     user,
+		CSRF:     csrf.Token(r),
+		Stats:    stats,
+		Guests:   guests[:min(len(guests), pageSize)],
+		Page:     page,
+		PrevPage: page - 1,
+	}
+
+	if len(guests) > pageSize {
+		data.NextPage = page + 1
+	}
+
+	w.Header().Add("Content-Type", "text/html")
+	h.tmpl.ExecuteTemplate(w, "dashboard.html", data)
+}
+
+func (h *Handler) guestID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return uuid.Nil, false
+	}
+
+	return id, true
+}
+
+func (h *Handler) redirectBack(w http.ResponseWriter, r *http.Request) {
+	target := "/admin"
+	if page := r.URL.Query().Get("page"); page != "" {
+		target += "?page=" + url.QueryEscape(page)
+	}
+
+	http.Redirect(w, r, target, http.StatusSeeOther)
+}
+
+// Approve publishes an entry.
+func (h *Handler) Approve(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.guestID(w, r)
+	if !ok {
+		return
+	}
+
+	user, _ := UserFromContext(r.Context())
+
+	_, err := h.moderator.Approve(r.Context(), id, user.Username)
+	if errors.Is(err, moderation.ErrNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	} else if err != nil {
+		h.logger.Error("failed to approve guest", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	h.redirectBack(w, r)
+}
+
+// Hide removes an entry from public view without deleting it.
+func (h *Handler) Hide(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.guestID(w, r)
+	if !ok {
+		return
+	}
+
+	user, _ := UserFromContext(r.Context())
+
+	_, err := h.moderator.Reject(r.Context(), id, user.Username)
+	if errors.Is(err, moderation.ErrNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	} else if err != nil {
+		h.logger.Error("failed to hide guest", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	h.redirectBack(w, r)
+}
+
+// Delete permanently removes an entry.
+func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.guestID(w, r)
+	if !ok {
+		return
+	}
+
+	deleted, err := h.repo.DeleteGuest(r.Context(), id)
+	if err != nil {
+		h.logger.Error("failed to delete guest", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if deleted == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	user, _ := UserFromContext(r.Context())
+	h.logger.Info(
+		"deleted guest",
+		slog.String("id", id.String()),
+		slog.String("admin", user.Username),
+	)
+
+	h.redirectBack(w, r)
+}
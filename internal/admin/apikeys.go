@@ -0,0 +1,128 @@
+package admin
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/dreamsofcode-io/guestbook/internal/csrf"
+	"github.com/dreamsofcode-io/guestbook/internal/repository"
+)
+
+const maxAPIKeyName = 100
+
+type apiKeysPage struct {
+	User User
+	CSRF string
+	Keys []repository.APIKey
+	// NewKey is the key that was just created. It is only shown once, as
+	// only its hash is stored.
+	NewKey       string
+	ErrorMessage string
+}
+
+// ValidAPIKey reports whether the key is one an admin has handed out and
+// not revoked. A key that can't be looked up isn't valid.
+func ValidAPIKey(ctx context.Context, repo *repository.Queries, key string) bool {
+	_, err := repo.FindAPIKey(ctx, hashToken(key))
+	return err == nil
+}
+
+func (h *Handler) renderAPIKeys(
+	w http.ResponseWriter, r *http.Request, statusCode int, page apiKeysPage,
+) {
+	keys, err := h.repo.ListAPIKeys(r.Context())
+	if err != nil {
+		h.logger.Error("failed to list api keys", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	page.User, _ = UserFromContext(r.Context())
+	page.CSRF = csrf.Token(r)
+	page.Keys = keys
+
+	w.Header().Add("Content-Type", "text/html")
+	w.WriteHeader(statusCode)
+	h.tmpl.ExecuteTemplate(w, "apikeys.html", page)
+}
+
+// APIKeys lists the keys handed out to API clients.
+func (h *Handler) APIKeys(w http.ResponseWriter, r *http.Request) {
+	h.renderAPIKeys(w, r, http.StatusOK, apiKeysPage{})
+}
+
+// CreateAPIKey hands out a new key to an API client and shows it once.
+func (h *Handler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	name := strings.TrimSpace(r.PostForm.Get("name"))
+	if name == "" || len(name) > maxAPIKeyName {
+		h.renderAPIKeys(w, r, http.StatusBadRequest, apiKeysPage{
+			ErrorMessage: fmt.Sprintf("Give the key a name of at most %d characters", maxAPIKeyName),
+		})
+		return
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		h.logger.Error("failed to generate api key", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	key := base64.RawURLEncoding.EncodeToString(buf)
+	user, _ := UserFromContext(r.Context())
+
+	_, err := h.repo.CreateAPIKey(r.Context(), repository.CreateAPIKeyParams{
+		ID:        uuid.New(),
+		Name:      name,
+		KeyHash:   hashToken(key),
+		CreatedBy: user.Username,
+		CreatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		h.logger.Error("failed to create api key", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	h.logger.Info(
+		"created api key",
+		slog.String("name", name),
+		slog.String("admin", user.Username),
+	)
+
+	h.renderAPIKeys(w, r, http.StatusCreated, apiKeysPage{NewKey: key})
+}
+
+// RevokeAPIKey stops a key from being accepted.
+func (h *Handler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	rows, err := h.repo.RevokeAPIKey(r.Context(), id)
+	if err != nil {
+		h.logger.Error("failed to revoke api key", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	} else if rows == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	http.Redirect(w, r, "/admin/api-keys", http.StatusSeeOther)
+}
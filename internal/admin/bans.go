@@ -0,0 +1,110 @@
+package admin
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/dreamsofcode-io/guestbook/internal/ban"
+	"github.com/dreamsofcode-io/guestbook/internal/csrf"
+	"github.com/dreamsofcode-io/guestbook/internal/repository"
+)
+
+type bansPage struct {
+	User         User
+	CSRF         string
+	Bans         []repository.Ban
+	ErrorMessage string
+}
+
+func (h *Handler) renderBans(
+	w http.ResponseWriter, r *http.Request, statusCode int, message string,
+) {
+	user, _ := UserFromContext(r.Context())
+
+	bans, err := h.bans.Active(r.Context())
+	if err != nil {
+		h.logger.Error("failed to list bans", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add("Content-Type", "text/html")
+	w.WriteHeader(statusCode)
+	h.tmpl.ExecuteTemplate(w, "bans.html", bansPage{
+		User:         user,
+		CSRF:         csrf.Token(r),
+		Bans:         bans,
+		ErrorMessage: message,
+	})
+}
+
+// Bans lists every active ban.
+func (h *Handler) Bans(w http.ResponseWriter, r *http.Request) {
+	h.renderBans(w, r, http.StatusOK, "")
+}
+
+// AddBan bans an IP address or CIDR range, optionally for a limited time.
+func (h *Handler) AddBan(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	network, err := ban.ParseNetwork(r.PostForm.Get("network"))
+	if err != nil {
+		h.renderBans(w, r, http.StatusBadRequest, "Invalid IP address or CIDR range")
+		return
+	}
+
+	var ttl time.Duration
+	if value := strings.TrimSpace(r.PostForm.Get("duration")); value != "" {
+		ttl, err = time.ParseDuration(value)
+		if err != nil || ttl <= 0 {
+			h.renderBans(w, r, http.StatusBadRequest, "Invalid duration, use a value like 24h")
+			return
+		}
+	}
+
+	user, _ := UserFromContext(r.Context())
+
+	_, err = h.bans.Add(r.Context(), network, r.PostForm.Get("reason"), user.Username, ttl)
+	if err != nil {
+		h.logger.Error("failed to add ban", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	h.logger.Info(
+		"added ban",
+		slog.String("network", network.String()),
+		slog.String("admin", user.Username),
+	)
+
+	http.Redirect(w, r, "/admin/bans", http.StatusSeeOther)
+}
+
+// LiftBan removes a ban before it expires.
+func (h *Handler) LiftBan(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	err = h.bans.Lift(r.Context(), id)
+	if errors.Is(err, ban.ErrNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	} else if err != nil {
+		h.logger.Error("failed to lift ban", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/admin/bans", http.StatusSeeOther)
+}
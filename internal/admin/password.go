@@ -0,0 +1,88 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/dreamsofcode-io/guestbook/internal/repository"
+)
+
+const minPasswordLength = 12
+
+var (
+	ErrInvalidUsername     = errors.New("username is required")
+	ErrPasswordTooShort    = fmt.Errorf("password must be at least %d characters", minPasswordLength)
+	ErrInvalidCredentials  = errors.New("invalid username or password")
+	errMissingPasswordHash = errors.New("missing password hash")
+)
+
+// dummyHash is compared against when a username doesn't exist, so that
+// failed logins take the same time whether or not the user exists.
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("guestbook-dummy-password"), bcrypt.DefaultCost)
+
+// CreateUser stores a new admin user with a bcrypt hash of their password.
+func CreateUser(
+	ctx context.Context, db repository.DBTX, username, password string,
+) (repository.AdminUser, error) {
+	username = strings.TrimSpace(username)
+	if username == "" {
+		return repository.AdminUser{}, ErrInvalidUsername
+	}
+
+	if len(password) < minPasswordLength {
+		return repository.AdminUser{}, ErrPasswordTooShort
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return repository.AdminUser{}, fmt.Errorf("hash password: %w", err)
+	}
+
+	id, err := uuid.NewV7()
+	if err != nil {
+		return repository.AdminUser{}, fmt.Errorf("create id: %w", err)
+	}
+
+	user, err := repository.New(db).CreateAdmin(ctx, repository.CreateAdminParams{
+		ID:           id,
+		Username:     username,
+		PasswordHash: string(hash),
+		CreatedAt:    time.Now().UTC(),
+	})
+	if err != nil {
+		return repository.AdminUser{}, fmt.Errorf("create admin: %w", err)
+	}
+
+	return user, nil
+}
+
+// authenticate checks the username and password against the stored hash.
+func authenticate(
+	ctx context.Context, repo *repository.Queries, username, password string,
+) (repository.AdminUser, error) {
+	user, err := repo.FindAdminByUsername(ctx, username)
+	if errors.Is(err, pgx.ErrNoRows) {
+		bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+		return repository.AdminUser{}, ErrInvalidCredentials
+	} else if err != nil {
+		return repository.AdminUser{}, fmt.Errorf("find admin: %w", err)
+	}
+
+	if user.PasswordHash == "" {
+		return repository.AdminUser{}, errMissingPasswordHash
+	}
+
+	err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password))
+	if err != nil {
+		return repository.AdminUser{}, ErrInvalidCredentials
+	}
+
+	return user, nil
+}
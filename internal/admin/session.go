@@ -0,0 +1,193 @@
+package admin
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/dreamsofcode-io/guestbook/internal/clientip"
+	"github.com/dreamsofcode-io/guestbook/internal/csrf"
+	"github.com/dreamsofcode-io/guestbook/internal/repository"
+)
+
+// SessionCookie is the name of the cookie holding the admin session token.
+const SessionCookie = "guestbook_admin"
+
+type contextKey struct{}
+
+// User is the admin that is signed in for the current request.
+type User struct {
+	ID       uuid.UUID
+	Username string
+}
+
+// UserFromContext returns the signed in admin, if there is one.
+func UserFromContext(ctx context.Context) (User, bool) {
+	user, ok := ctx.Value(contextKey{}).(User)
+	return user, ok
+}
+
+func hashToken(token string) []byte {
+	sum := sha256.Sum256([]byte(token))
+	return sum[:]
+}
+
+// startSession creates a new session for the user and sets its cookie. Only
+// a hash of the token is stored, so a database leak can't be used to sign
+// in.
+func (h *Handler) startSession(
+	w http.ResponseWriter, r *http.Request, user repository.AdminUser,
+) error {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return fmt.Errorf("generate token: %w", err)
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(buf)
+	now := time.Now().UTC()
+	expires := now.Add(h.cfg.SessionTTL)
+
+	err := h.repo.CreateSession(r.Context(), repository.CreateSessionParams{
+		TokenHash: hashToken(token),
+		AdminID:   user.ID,
+		CreatedAt: now,
+		ExpiresAt: expires,
+	})
+	if err != nil {
+		return fmt.Errorf("create session: %w", err)
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     SessionCookie,
+		Value:    token,
+		Path:     "/admin",
+		Expires:  expires,
+		HttpOnly: true,
+		Secure:   clientip.Secure(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	return nil
+}
+
+func (h *Handler) endSession(w http.ResponseWriter, r *http.Request) error {
+	http.SetCookie(w, &http.Cookie{
+		Name:     SessionCookie,
+		Value:    "",
+		Path:     "/admin",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   clientip.Secure(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	cookie, err := r.Cookie(SessionCookie)
+	if err != nil {
+		return nil
+	}
+
+	return h.repo.DeleteSession(r.Context(), hashToken(cookie.Value))
+}
+
+// ValidSession reports whether the token belongs to a session that hasn't
+// expired. A session that can't be looked up isn't valid.
+func ValidSession(ctx context.Context, repo *repository.Queries, token string) bool {
+	_, err := repo.FindSession(ctx, hashToken(token))
+	return err == nil
+}
+
+// RequireSession only lets requests with a valid session through, anyone
+// else is redirected to the login page.
+func (h *Handler) RequireSession(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie(SessionCookie)
+		if err != nil {
+			http.Redirect(w, r, "/admin/login", http.StatusSeeOther)
+			return
+		}
+
+		session, err := h.repo.FindSession(r.Context(), hashToken(cookie.Value))
+		if errors.Is(err, pgx.ErrNoRows) {
+			http.Redirect(w, r, "/admin/login", http.StatusSeeOther)
+			return
+		} else if err != nil {
+			h.logger.Error("failed to find session", slog.Any("error", err))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), contextKey{}, User{
+			ID:       session.ID,
+			Username: session.Username,
+		})
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+type loginPage struct {
+	CSRF         string
+	Username     string
+	ErrorMessage string
+}
+
+func (h *Handler) LoginForm(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "text/html")
+	h.tmpl.ExecuteTemplate(w, "login.html", loginPage{CSRF: csrf.Token(r)})
+}
+
+func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	username := r.PostForm.Get("username")
+
+	user, err := authenticate(r.Context(), h.repo, username, r.PostForm.Get("password"))
+	if errors.Is(err, ErrInvalidCredentials) {
+		h.logger.Warn("failed admin login", slog.String("username", username))
+		w.Header().Add("Content-Type", "text/html")
+		w.WriteHeader(http.StatusUnauthorized)
+		h.tmpl.ExecuteTemplate(w, "login.html", loginPage{
+			CSRF:         csrf.Token(r),
+			Username:     username,
+			ErrorMessage: "Invalid username or password",
+		})
+		return
+	} else if err != nil {
+		h.logger.Error("failed to authenticate", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.startSession(w, r, user); err != nil {
+		h.logger.Error("failed to start session", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	// Expired sessions are cleaned up lazily whenever someone signs in.
+	if err := h.repo.DeleteExpiredSessions(r.Context()); err != nil {
+		h.logger.Warn("failed to delete expired sessions", slog.Any("error", err))
+	}
+
+	http.Redirect(w, r, "/admin", http.StatusSeeOther)
+}
+
+func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
+	if err := h.endSession(w, r); err != nil {
+		h.logger.Error("failed to end session", slog.Any("error", err))
+	}
+
+	http.Redirect(w, r, "/admin/login", http.StatusSeeOther)
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"html/template"
+	"io"
 	"io/fs"
 	"log/slog"
 	"net/http"
@@ -14,8 +15,16 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/redis/go-redis/v9"
 
+	"github.com/dreamsofcode-io/guestbook/internal/admin"
+	"github.com/dreamsofcode-io/guestbook/internal/clientip"
+	"github.com/dreamsofcode-io/guestbook/internal/config"
+	"github.com/dreamsofcode-io/guestbook/internal/csrf"
 	"github.com/dreamsofcode-io/guestbook/internal/database"
+	"github.com/dreamsofcode-io/guestbook/internal/events"
+	"github.com/dreamsofcode-io/guestbook/internal/filter"
+	"github.com/dreamsofcode-io/guestbook/internal/handler"
 	"github.com/dreamsofcode-io/guestbook/internal/middleware"
+	"github.com/dreamsofcode-io/guestbook/internal/repository"
 )
 
 type App struct {
@@ -23,6 +32,26 @@ type App struct {
 	router     *http.ServeMux
 	db         *pgxpool.Pool
 	rdb        *redis.Client
+	pages      *config.Pagination
+	mod        *config.Moderation
+	threads    *config.Threads
+	markdown   *config.Markdown
+	editing    *config.Editing
+	pow        *config.ProofOfWork
+	honeypot   *config.Honeypot
+	admin      *config.Admin
+	filters    *filter.Pipeline
+	resolver   *clientip.Resolver
+	limitStore middleware.RateLimitStore
+	rateLimit  *config.RateLimit
+	limits     map[string]*rateLimitPolicy
+	events     *config.Events
+	broker     *events.Broker
+	websocket  *config.WebSocket
+	socket     *handler.Socket
+	feed       *config.Feed
+	secret     *config.Secret
+	csrf       *csrf.Protector
 	migrations fs.FS
 	templates  fs.FS
 }
@@ -56,15 +85,43 @@ func (a *App) Start(ctx context.Context) error {
 
 	a.db = db
 
-	tmpl := template.Must(template.New("").ParseFS(a.templates, "templates/*"))
+	if err := a.loadConfig(); err != nil {
+		return err
+	}
+
+	if closer, ok := a.limitStore.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	tmpl := template.Must(template.New("").Funcs(csrf.Funcs).ParseFS(a.templates, "templates/*.html"))
+	adminTmpl := template.Must(
+		template.New("").Funcs(csrf.Funcs).ParseFS(a.templates, "templates/admin/*.html"),
+	)
+
+	repo := repository.New(a.db)
+	a.csrf = csrf.New(a.secret.Key, tmpl, func(ctx context.Context, key string) bool {
+		return admin.ValidAPIKey(ctx, repo, key)
+	})
 
 	a.loadRoutes(tmpl)
+	a.loadAdminRoutes(adminTmpl)
 
 	server := http.Server{
-		Addr:    ":8080",
-		Handler: middleware.Logging(a.logger, middleware.HandleBadCode(tmpl, a.router)),
+		Addr: ":8080",
+		Handler: a.resolver.Middleware(
+			middleware.Logging(a.logger, middleware.HandleBadCode(tmpl, a.router)),
+		),
 	}
 
+	// Entries published by any replica are announced through postgres
+	listenCtx, stopListening := context.WithCancel(ctx)
+	defer stopListening()
+
+	go events.NewListener(a.logger, a.db, a.broker).Run(listenCtx)
+
+	// Live streams never finish on their own, so end them when shutting down
+	server.RegisterOnShutdown(a.broker.Close)
+
 	done := make(chan struct{})
 	go func() {
 		err := server.ListenAndServe()
@@ -81,6 +138,9 @@ func (a *App) Start(ctx context.Context) error {
 	case <-ctx.Done():
 		ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
 		server.Shutdown(ctx)
+		// Shutdown doesn't track hijacked connections, so wait for the
+		// websockets to close themselves
+		a.socket.Wait(ctx)
 		cancel()
 	}
 
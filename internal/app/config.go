@@ -0,0 +1,135 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/dreamsofcode-io/guestbook/internal/clientip"
+	"github.com/dreamsofcode-io/guestbook/internal/config"
+	"github.com/dreamsofcode-io/guestbook/internal/events"
+	"github.com/dreamsofcode-io/guestbook/internal/filter"
+)
+
+// loadConfig loads every configuration from the environment and builds the
+// components that depend on it.
+func (a *App) loadConfig() error {
+	pages, err := config.NewPagination()
+	if err != nil {
+		return fmt.Errorf("failed to load pagination config: %w", err)
+	}
+
+	a.pages = pages
+
+	mod, err := config.NewModeration()
+	if err != nil {
+		return fmt.Errorf("failed to load moderation config: %w", err)
+	}
+
+	a.mod = mod
+
+	threads, err := config.NewThreads()
+	if err != nil {
+		return fmt.Errorf("failed to load threads config: %w", err)
+	}
+
+	a.threads = threads
+
+	markdown, err := config.NewMarkdown()
+	if err != nil {
+		return fmt.Errorf("failed to load markdown config: %w", err)
+	}
+
+	a.markdown = markdown
+
+	editing, err := config.NewEditing()
+	if err != nil {
+		return fmt.Errorf("failed to load editing config: %w", err)
+	}
+
+	a.editing = editing
+
+	pow, err := config.NewProofOfWork()
+	if err != nil {
+		return fmt.Errorf("failed to load proof of work config: %w", err)
+	}
+
+	a.pow = pow
+
+	honeypot, err := config.NewHoneypot()
+	if err != nil {
+		return fmt.Errorf("failed to load honeypot config: %w", err)
+	}
+
+	a.honeypot = honeypot
+
+	admin, err := config.NewAdmin()
+	if err != nil {
+		return fmt.Errorf("failed to load admin config: %w", err)
+	}
+
+	a.admin = admin
+
+	filterCfg, err := config.NewFilters()
+	if err != nil {
+		return fmt.Errorf("failed to load filter config: %w", err)
+	}
+
+	filters, err := filter.FromConfig(a.logger, filterCfg)
+	if err != nil {
+		return fmt.Errorf("failed to create content filters: %w", err)
+	}
+
+	a.filters = filters
+
+	proxy, err := config.NewProxy()
+	if err != nil {
+		return fmt.Errorf("failed to load proxy config: %w", err)
+	}
+
+	a.resolver = clientip.NewResolver(proxy.Trusted, proxy.Header)
+
+	eventsCfg, err := config.NewEvents()
+	if err != nil {
+		return fmt.Errorf("failed to load events config: %w", err)
+	}
+
+	a.events = eventsCfg
+	a.broker = events.NewBroker(eventsCfg.MaxPerClient)
+
+	feed, err := config.NewFeed()
+	if err != nil {
+		return fmt.Errorf("failed to load feed config: %w", err)
+	}
+
+	a.feed = feed
+
+	websocket, err := config.NewWebSocket()
+	if err != nil {
+		return fmt.Errorf("failed to load websocket config: %w", err)
+	}
+
+	a.websocket = websocket
+
+	secret, err := config.NewSecret()
+	if err != nil {
+		return fmt.Errorf("failed to load secret: %w", err)
+	}
+
+	if secret.Generated {
+		a.logger.Warn("SECRET_KEY is not set in development, using a random key that is lost on restart")
+	}
+
+	a.secret = secret
+
+	rateLimit, err := config.NewRateLimit()
+	if err != nil {
+		return fmt.Errorf("failed to load rate limit config: %w", err)
+	}
+
+	a.rateLimit = rateLimit
+
+	if err := a.loadRateLimits(rateLimit); err != nil {
+		return fmt.Errorf("failed to load rate limits: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,196 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+
+	"github.com/dreamsofcode-io/guestbook/internal/admin"
+	"github.com/dreamsofcode-io/guestbook/internal/config"
+	"github.com/dreamsofcode-io/guestbook/internal/middleware"
+	"github.com/dreamsofcode-io/guestbook/internal/repository"
+)
+
+// rateLimitPolicy is a configured policy together with the routes it has
+// been applied to.
+type rateLimitPolicy struct {
+	name    string
+	config  config.RateLimitPolicy
+	limiter *middleware.RateLimiter
+	routes  []string
+}
+
+func (a *App) newRateLimitStore(cfg *config.RateLimit) middleware.RateLimitStore {
+	if cfg.Store == config.RateLimitStoreMemory {
+		return middleware.NewMemoryStore(0, 0)
+	}
+
+	var fallback middleware.RateLimitStore
+	if cfg.Failure == config.RateLimitFailFallback {
+		fallback = middleware.NewMemoryStore(0, 0)
+	}
+
+	return middleware.NewResilientStore(
+		a.logger,
+		middleware.NewRedisStore(a.rdb),
+		fallback,
+		cfg.Timeout,
+		middleware.NewBreaker(cfg.BreakerThreshold, cfg.BreakerCooldown),
+	)
+}
+
+func (a *App) rateLimitKey(key string) middleware.KeyFunc {
+	switch key {
+	case config.RateLimitKeyAPIKey:
+		repo := repository.New(a.db)
+
+		return middleware.KeyByAPIKey(func(ctx context.Context, key string) bool {
+			return admin.ValidAPIKey(ctx, repo, key)
+		})
+	case config.RateLimitKeySession:
+		repo := repository.New(a.db)
+
+		return middleware.KeyBySession(
+			admin.SessionCookie,
+			func(ctx context.Context, token string) bool {
+				return admin.ValidSession(ctx, repo, token)
+			},
+		)
+	default:
+		return middleware.KeyByIP
+	}
+}
+
+// loadRateLimits builds a limiter for every enabled policy, sharing the
+// configured store between them.
+func (a *App) loadRateLimits(cfg *config.RateLimit) error {
+	a.limitStore = a.newRateLimitStore(cfg)
+	a.limits = map[string]*rateLimitPolicy{}
+
+	for name, policy := range cfg.Policies {
+		p := &rateLimitPolicy{
+			name:   name,
+			config: policy,
+		}
+
+		a.limits[name] = p
+
+		if policy.Disabled {
+			continue
+		}
+
+		algorithm, err := middleware.ParseAlgorithm(policy.Algorithm)
+		if err != nil {
+			return fmt.Errorf("invalid policy %q: %w", name, err)
+		}
+
+		p.limiter = &middleware.RateLimiter{
+			Name: name,
+			Limit: middleware.Limit{
+				Algorithm: algorithm,
+				Rate:      policy.Rate,
+				Period:    policy.Period.Duration,
+				Burst:     policy.Burst,
+			},
+			Store:      a.limitStore,
+			Key:        a.rateLimitKey(policy.Key),
+			FailClosed: cfg.Failure == config.RateLimitFailClosed,
+		}
+	}
+
+	return nil
+}
+
+// limiter returns the limiter for the named policy, or nil if it is missing
+// or disabled.
+func (a *App) limiter(policy string) *middleware.RateLimiter {
+	if p, ok := a.limits[policy]; ok {
+		return p.limiter
+	}
+
+	return nil
+}
+
+// handle registers the handler for the pattern behind the named rate limit
+// policy. Routes whose policy is missing or disabled are not limited.
+func (a *App) handle(pattern string, policy string, h http.Handler) {
+	p, ok := a.limits[policy]
+	if !ok {
+		a.logger.Warn("no rate limit policy for route",
+			slog.String("policy", policy), slog.String("route", pattern),
+		)
+		a.router.Handle(pattern, h)
+		return
+	}
+
+	p.routes = append(p.routes, pattern)
+
+	if p.limiter != nil {
+		h = p.limiter.Middleware(h)
+	}
+
+	a.router.Handle(pattern, h)
+}
+
+type rateLimitInfo struct {
+	Name      string   `json:"name"`
+	Disabled  bool     `json:"disabled"`
+	Algorithm string   `json:"algorithm"`
+	Rate      int64    `json:"rate"`
+	Period    string   `json:"period"`
+	Burst     int64    `json:"burst,omitempty"`
+	Key       string   `json:"key"`
+	Routes    []string `json:"routes"`
+}
+
+// rateLimits reports the configured policies, the routes using them and
+// whether the store is degraded.
+func (a *App) rateLimits(w http.ResponseWriter, r *http.Request) {
+	res := struct {
+		Store    string                          `json:"store"`
+		Failure  string                          `json:"failure"`
+		Health   *middleware.ResilientStoreStats `json:"health,omitempty"`
+		Policies []rateLimitInfo                 `json:"policies"`
+	}{
+		Store:    a.rateLimit.Store,
+		Failure:  a.rateLimit.Failure,
+		Policies: make([]rateLimitInfo, 0, len(a.limits)),
+	}
+
+	if store, ok := a.limitStore.(*middleware.ResilientStore); ok {
+		stats := store.Stats()
+		res.Health = &stats
+	}
+
+	for _, p := range a.limits {
+		routes := p.routes
+		if routes == nil {
+			routes = []string{}
+		}
+
+		res.Policies = append(res.Policies, rateLimitInfo{
+			Name:      p.name,
+			Disabled:  p.config.Disabled,
+			Algorithm: p.config.Algorithm,
+			Rate:      p.config.Rate,
+			Period:    p.config.Period.String(),
+			Burst:     p.config.Burst,
+			Key:       p.config.Key,
+			Routes:    routes,
+		})
+	}
+
+	sort.Slice(res.Policies, func(i, j int) bool {
+		return res.Policies[i].Name < res.Policies[j].Name
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(res); err != nil {
+		a.logger.Error("failed to encode response", slog.Any("error", err))
+	}
+}
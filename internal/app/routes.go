@@ -4,17 +4,101 @@ import (
 	"html/template"
 	"net/http"
 
+	"github.com/dreamsofcode-io/guestbook/internal/admin"
+	"github.com/dreamsofcode-io/guestbook/internal/edittoken"
 	"github.com/dreamsofcode-io/guestbook/internal/handler"
+	"github.com/dreamsofcode-io/guestbook/internal/honeypot"
+	"github.com/dreamsofcode-io/guestbook/internal/identicon"
+	"github.com/dreamsofcode-io/guestbook/internal/middleware"
+	"github.com/dreamsofcode-io/guestbook/internal/pow"
+	"github.com/dreamsofcode-io/guestbook/internal/reaction"
 )
 
 func (a *App) loadRoutes(tmpl *template.Template) {
-	guestbook := handler.New(a.logger, a.db, tmpl)
+	reactions := reaction.New(a.db, a.secret.Key)
+	guestbook := handler.New(
+		a.logger, a.db, tmpl, a.pages, a.mod, a.filters, a.broker, reactions,
+		a.threads, a.markdown, identicon.New(a.secret.Key),
+		a.editing, edittoken.New(a.secret.Key),
+		// Proof of work gets its own breaker, as it talks to redis apart
+		// from the rate limiter.
+		pow.New(
+			a.logger, a.secret.Key, a.rdb,
+			middleware.NewBreaker(a.rateLimit.BreakerThreshold, a.rateLimit.BreakerCooldown),
+			a.pow,
+		),
+		honeypot.New(a.secret.Key, a.honeypot),
+	)
+	api := handler.NewAPI(guestbook)
+	stream := handler.NewStream(guestbook, a.events)
+	feed := handler.NewFeed(guestbook, a.feed)
+	a.socket = handler.NewSocket(guestbook, a.events, a.websocket, a.limiter("post"))
 
 	files := http.FileServer(http.Dir("./static"))
 
 	a.router.Handle("GET /static/", http.StripPrefix("/static", files))
 
-	a.router.Handle("GET /{$}", http.HandlerFunc(guestbook.Home))
+	// Pages with forms hand out the token that submitting them requires.
+	forms := a.csrf.Middleware
 
-	a.router.Handle("POST /{$}", http.HandlerFunc(guestbook.Create))
+	a.handle("GET /{$}", "home", forms(http.HandlerFunc(guestbook.Home)))
+
+	a.handle("POST /{$}", "post", forms(http.HandlerFunc(guestbook.Create)))
+	a.handle("GET /b/{slug}", "home", forms(http.HandlerFunc(guestbook.Home)))
+	a.handle("POST /b/{slug}", "post", forms(http.HandlerFunc(guestbook.Create)))
+	a.handle("GET /guests/{id}", "home", forms(http.HandlerFunc(guestbook.Thread)))
+	a.handle(
+		"GET /guests/{id}/edit", "home",
+		middleware.NoCache(forms(http.HandlerFunc(guestbook.EditForm))),
+	)
+	a.handle("POST /guests/{id}/edit", "edit", forms(http.HandlerFunc(guestbook.Edit)))
+	a.handle("POST /guests/{id}/delete", "edit", forms(http.HandlerFunc(guestbook.Delete)))
+	a.handle("GET /guests/{id}/identicon.svg", "home", http.HandlerFunc(guestbook.Identicon))
+	a.handle("POST /guests/{id}/reactions", "react", forms(http.HandlerFunc(guestbook.React)))
+
+	a.handle("GET /feed.rss", "home", http.HandlerFunc(feed.RSS))
+	a.handle("GET /feed.atom", "home", http.HandlerFunc(feed.Atom))
+	a.handle("GET /b/{slug}/feed.rss", "home", http.HandlerFunc(feed.RSS))
+	a.handle("GET /b/{slug}/feed.atom", "home", http.HandlerFunc(feed.Atom))
+
+	a.handle("GET /events", "events", http.HandlerFunc(stream.Events))
+	a.handle("GET /ws", "events", http.HandlerFunc(a.socket.Serve))
+
+	a.handle("GET /api/v1/guests", "api", http.HandlerFunc(api.List))
+	a.handle("POST /api/v1/guests", "api_write", a.csrf.API(http.HandlerFunc(api.Create)))
+	a.handle("GET /api/v1/guests/count", "api", http.HandlerFunc(api.Count))
+	a.handle("GET /api/v1/challenge", "api", http.HandlerFunc(api.Challenge))
+	a.handle("GET /api/v1/guests/{id}", "api", http.HandlerFunc(api.Get))
+	a.handle(
+		"POST /api/v1/guests/{id}/reactions", "react",
+		a.csrf.API(http.HandlerFunc(api.React)),
+	)
+	a.handle("GET /api/v1/ratelimits", "api", http.HandlerFunc(a.rateLimits))
+}
+
+func (a *App) loadAdminRoutes(tmpl *template.Template) {
+	admin := admin.New(a.logger, a.db, tmpl, a.admin)
+
+	public := middleware.Chain(middleware.NoCache, a.csrf.Middleware)
+	private := middleware.Chain(middleware.NoCache, a.csrf.Middleware, admin.RequireSession)
+
+	a.handle("GET /admin/login", "admin", public(http.HandlerFunc(admin.LoginForm)))
+	a.handle("POST /admin/login", "admin_login", public(http.HandlerFunc(admin.Login)))
+
+	router := http.NewServeMux()
+
+	router.Handle("GET /admin", http.HandlerFunc(admin.Dashboard))
+	router.Handle("POST /admin/logout", http.HandlerFunc(admin.Logout))
+	router.Handle("POST /admin/guests/{id}/approve", http.HandlerFunc(admin.Approve))
+	router.Handle("POST /admin/guests/{id}/hide", http.HandlerFunc(admin.Hide))
+	router.Handle("POST /admin/guests/{id}/delete", http.HandlerFunc(admin.Delete))
+	router.Handle("GET /admin/bans", http.HandlerFunc(admin.Bans))
+	router.Handle("POST /admin/bans", http.HandlerFunc(admin.AddBan))
+	router.Handle("POST /admin/bans/{id}/lift", http.HandlerFunc(admin.LiftBan))
+	router.Handle("GET /admin/api-keys", http.HandlerFunc(admin.APIKeys))
+	router.Handle("POST /admin/api-keys", http.HandlerFunc(admin.CreateAPIKey))
+	router.Handle("POST /admin/api-keys/{id}/revoke", http.HandlerFunc(admin.RevokeAPIKey))
+
+	a.handle("/admin", "admin", private(router))
+	a.handle("/admin/", "admin", private(router))
 }
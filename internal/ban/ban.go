@@ -0,0 +1,142 @@
+// Package ban manages the list of IP addresses and networks that are not
+// allowed to post.
+package ban
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/dreamsofcode-io/guestbook/internal/repository"
+)
+
+var (
+	ErrNotFound       = errors.New("ban not found")
+	ErrInvalidNetwork = errors.New("invalid ip address or cidr")
+	ErrMissingAuthor  = errors.New("ban author is required")
+)
+
+// List stores bans and checks addresses against them.
+type List struct {
+	repo *repository.Queries
+}
+
+func New(db repository.DBTX) *List {
+	return &List{
+		repo: repository.New(db),
+	}
+}
+
+// ParseNetwork parses either a single IP address or a CIDR range. Single
+// addresses become a /32 or /128 network.
+func ParseNetwork(s string) (netip.Prefix, error) {
+	s = strings.TrimSpace(s)
+
+	if strings.Contains(s, "/") {
+		prefix, err := netip.ParsePrefix(s)
+		if err != nil {
+			return netip.Prefix{}, ErrInvalidNetwork
+		}
+
+		return prefix.Masked(), nil
+	}
+
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, ErrInvalidNetwork
+	}
+
+	addr = addr.Unmap()
+
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// Add bans a network. A zero ttl means the ban never expires.
+func (l *List) Add(
+	ctx context.Context, network netip.Prefix, reason, by string,
+	ttl time.Duration,
+) (repository.Ban, error) {
+	if by == "" {
+		return repository.Ban{}, ErrMissingAuthor
+	}
+
+	id, err := uuid.NewV7()
+	if err != nil {
+		return repository.Ban{}, fmt.Errorf("create id: %w", err)
+	}
+
+	now := time.Now().UTC()
+
+	var expires pgtype.Timestamptz
+	if ttl > 0 {
+		expires = pgtype.Timestamptz{Time: now.Add(ttl), Valid: true}
+	}
+
+	ban, err := l.repo.CreateBan(ctx, repository.CreateBanParams{
+		ID:        id,
+		Network:   network,
+		Reason:    reason,
+		CreatedBy: by,
+		CreatedAt: now,
+		ExpiresAt: expires,
+	})
+	if err != nil {
+		return repository.Ban{}, fmt.Errorf("create ban: %w", err)
+	}
+
+	return ban, nil
+}
+
+// Active returns every ban that hasn't expired.
+func (l *List) Active(ctx context.Context) ([]repository.Ban, error) {
+	bans, err := l.repo.ListActiveBans(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list bans: %w", err)
+	}
+
+	return bans, nil
+}
+
+// Lift removes a ban.
+func (l *List) Lift(ctx context.Context, id uuid.UUID) error {
+	deleted, err := l.repo.LiftBan(ctx, id)
+	if err != nil {
+		return fmt.Errorf("lift ban: %w", err)
+	}
+
+	if deleted == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// Check returns the most specific active ban covering the ip, if any.
+func (l *List) Check(ctx context.Context, ip net.IP) (repository.Ban, bool, error) {
+	if ip == nil {
+		return repository.Ban{}, false, nil
+	}
+
+	// A 16 byte IPv4 address is sent as ::ffff:a.b.c.d, which no IPv4 ban
+	// covers.
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+
+	ban, err := l.repo.FindActiveBan(ctx, ip)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return repository.Ban{}, false, nil
+	} else if err != nil {
+		return repository.Ban{}, false, fmt.Errorf("find ban: %w", err)
+	}
+
+	return ban, true, nil
+}
@@ -0,0 +1,104 @@
+package ban_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dreamsofcode-io/guestbook/internal/ban"
+)
+
+func TestParseNetwork(t *testing.T) {
+	testCases := []struct {
+		Input    string
+		Expected string
+		Err      error
+	}{
+		{Input: "192.0.2.1", Expected: "192.0.2.1/32"},
+		{Input: " 2001:db8::1 ", Expected: "2001:db8::1/128"},
+		{Input: "::ffff:192.0.2.1", Expected: "192.0.2.1/32"},
+		{Input: "192.0.2.77/24", Expected: "192.0.2.0/24"},
+		{Input: "2001:db8::/32", Expected: "2001:db8::/32"},
+		{Input: "not an ip", Err: ban.ErrInvalidNetwork},
+		{Input: "192.0.2.1/33", Err: ban.ErrInvalidNetwork},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.Input, func(t *testing.T) {
+			prefix, err := ban.ParseNetwork(test.Input)
+			if test.Err != nil {
+				assert.ErrorIs(t, err, test.Err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.Expected, prefix.String())
+		})
+	}
+}
+
+// recordingDB remembers the arguments of the last query and finds nothing.
+type recordingDB struct {
+	args []any
+}
+
+func (db *recordingDB) Exec(context.Context, string, ...any) (pgconn.CommandTag, error) {
+	return pgconn.CommandTag{}, nil
+}
+
+func (db *recordingDB) Query(context.Context, string, ...any) (pgx.Rows, error) {
+	return nil, pgx.ErrNoRows
+}
+
+func (db *recordingDB) QueryRow(_ context.Context, _ string, args ...any) pgx.Row {
+	db.args = args
+	return noRow{}
+}
+
+type noRow struct{}
+
+func (noRow) Scan(...any) error {
+	return pgx.ErrNoRows
+}
+
+func TestCheckUnmapsIPv4(t *testing.T) {
+	testCases := []struct {
+		Description string
+		IP          net.IP
+		Expected    net.IP
+	}{
+		{
+			Description: "16 byte ipv4",
+			IP:          net.ParseIP("192.0.2.1"),
+			Expected:    net.IPv4(192, 0, 2, 1).To4(),
+		},
+		{
+			Description: "4 byte ipv4",
+			IP:          net.IPv4(192, 0, 2, 1).To4(),
+			Expected:    net.IPv4(192, 0, 2, 1).To4(),
+		},
+		{
+			Description: "ipv6",
+			IP:          net.ParseIP("2001:db8::1"),
+			Expected:    net.ParseIP("2001:db8::1"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Description, func(t *testing.T) {
+			db := &recordingDB{}
+
+			_, banned, err := ban.New(db).Check(context.Background(), tc.IP)
+			require.NoError(t, err)
+			assert.False(t, banned)
+
+			require.Len(t, db.args, 1)
+			assert.Equal(t, tc.Expected, db.args[0])
+		})
+	}
+}
@@ -0,0 +1,175 @@
+// Package books manages the guestbooks a deployment serves and their
+// settings.
+package books
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/dreamsofcode-io/guestbook/internal/repository"
+)
+
+// Default is the slug of the book served at /, which holds every entry made
+// before there were several books.
+const Default = "default"
+
+// MaxMessageLength is the longest a book can allow messages to be, which is
+// as much as the database stores.
+const MaxMessageLength = 256
+
+var (
+	ErrNotFound         = errors.New("guestbook not found")
+	ErrInvalidSlug      = errors.New("slugs must be 1 to 40 lowercase letters, digits or dashes")
+	ErrSlugTaken        = errors.New("a guestbook with that slug already exists")
+	ErrMissingTitle     = errors.New("guestbook title is required")
+	ErrInvalidMaxLength = fmt.Errorf("max length must be between 0 and %d", MaxMessageLength)
+)
+
+var slugRe = regexp.MustCompile(`^[a-z0-9](?:[a-z0-9-]{0,38}[a-z0-9])?$`)
+
+// Settings are the parts of a book that can be changed after it is created.
+type Settings struct {
+	Title       string
+	Description string
+	// PreModeration holds new messages for review, on top of the global
+	// setting.
+	PreModeration bool
+	// MaxLength limits messages to fewer characters than the filters do.
+	// Zero leaves it to the filters.
+	MaxLength int32
+	// Open is false when the book doesn't accept new messages.
+	Open bool
+}
+
+// Validate checks the settings can be stored.
+func (s Settings) Validate() error {
+	if strings.TrimSpace(s.Title) == "" {
+		return ErrMissingTitle
+	}
+
+	if s.MaxLength < 0 || s.MaxLength > MaxMessageLength {
+		return ErrInvalidMaxLength
+	}
+
+	return nil
+}
+
+func settingsOf(b repository.Guestbook) Settings {
+	return Settings{
+		Title:         b.Title,
+		Description:   b.Description,
+		PreModeration: b.PreModeration,
+		MaxLength:     b.MaxLength,
+		Open:          b.Open,
+	}
+}
+
+// ParseSlug checks a slug can be used in the book's address.
+func ParseSlug(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if !slugRe.MatchString(s) {
+		return "", ErrInvalidSlug
+	}
+
+	return s, nil
+}
+
+// Store creates, lists and updates books.
+type Store struct {
+	repo *repository.Queries
+}
+
+func New(db repository.DBTX) *Store {
+	return &Store{
+		repo: repository.New(db),
+	}
+}
+
+// Create adds a new book.
+func (s *Store) Create(ctx context.Context, slug string, settings Settings) (repository.Guestbook, error) {
+	slug, err := ParseSlug(slug)
+	if err != nil {
+		return repository.Guestbook{}, err
+	}
+
+	if err := settings.Validate(); err != nil {
+		return repository.Guestbook{}, err
+	}
+
+	id, err := uuid.NewV7()
+	if err != nil {
+		return repository.Guestbook{}, fmt.Errorf("create id: %w", err)
+	}
+
+	b, err := s.repo.CreateGuestbook(ctx, repository.CreateGuestbookParams{
+		ID:            id,
+		Slug:          slug,
+		Title:         settings.Title,
+		Description:   settings.Description,
+		PreModeration: settings.PreModeration,
+		MaxLength:     settings.MaxLength,
+		Open:          settings.Open,
+		CreatedAt:     time.Now().UTC(),
+	})
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return repository.Guestbook{}, ErrSlugTaken
+	} else if err != nil {
+		return repository.Guestbook{}, fmt.Errorf("create guestbook: %w", err)
+	}
+
+	return b, nil
+}
+
+// List returns every book, oldest first.
+func (s *Store) List(ctx context.Context) ([]repository.Guestbook, error) {
+	list, err := s.repo.ListGuestbooks(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list guestbooks: %w", err)
+	}
+
+	return list, nil
+}
+
+// Update changes the settings of a book. The change function is given the
+// current settings to modify.
+func (s *Store) Update(
+	ctx context.Context, slug string, change func(*Settings),
+) (repository.Guestbook, error) {
+	b, err := s.repo.FindGuestbookBySlug(ctx, slug)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return repository.Guestbook{}, ErrNotFound
+	} else if err != nil {
+		return repository.Guestbook{}, fmt.Errorf("find guestbook: %w", err)
+	}
+
+	settings := settingsOf(b)
+	change(&settings)
+
+	if err := settings.Validate(); err != nil {
+		return repository.Guestbook{}, err
+	}
+
+	b, err = s.repo.UpdateGuestbook(ctx, repository.UpdateGuestbookParams{
+		ID:            b.ID,
+		Title:         settings.Title,
+		Description:   settings.Description,
+		PreModeration: settings.PreModeration,
+		MaxLength:     settings.MaxLength,
+		Open:          settings.Open,
+	})
+	if err != nil {
+		return repository.Guestbook{}, fmt.Errorf("update guestbook: %w", err)
+	}
+
+	return b, nil
+}
@@ -0,0 +1,80 @@
+package books_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dreamsofcode-io/guestbook/internal/books"
+)
+
+func TestParseSlug(t *testing.T) {
+	testCases := []struct {
+		Input    string
+		Expected string
+		Err      error
+	}{
+		{Input: "default", Expected: "default"},
+		{Input: " friends-2024 ", Expected: "friends-2024"},
+		{Input: "a", Expected: "a"},
+		{Input: "", Err: books.ErrInvalidSlug},
+		{Input: "Friends", Err: books.ErrInvalidSlug},
+		{Input: "-friends", Err: books.ErrInvalidSlug},
+		{Input: "friends-", Err: books.ErrInvalidSlug},
+		{Input: "friends/family", Err: books.ErrInvalidSlug},
+		{Input: "abcdefghijklmnopqrstuvwxyzabcdefghijklmno", Err: books.ErrInvalidSlug},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.Input, func(t *testing.T) {
+			slug, err := books.ParseSlug(test.Input)
+			if test.Err != nil {
+				assert.ErrorIs(t, err, test.Err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.Expected, slug)
+		})
+	}
+}
+
+func TestSettingsValidate(t *testing.T) {
+	testCases := []struct {
+		Description string
+		Settings    books.Settings
+		Err         error
+	}{
+		{
+			Description: "valid",
+			Settings:    books.Settings{Title: "Friends", MaxLength: 140, Open: true},
+		},
+		{
+			Description: "missing title",
+			Settings:    books.Settings{Title: "  "},
+			Err:         books.ErrMissingTitle,
+		},
+		{
+			Description: "negative max length",
+			Settings:    books.Settings{Title: "Friends", MaxLength: -1},
+			Err:         books.ErrInvalidMaxLength,
+		},
+		{
+			Description: "max length too long",
+			Settings:    books.Settings{Title: "Friends", MaxLength: books.MaxMessageLength + 1},
+			Err:         books.ErrInvalidMaxLength,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.Description, func(t *testing.T) {
+			err := test.Settings.Validate()
+			if test.Err != nil {
+				assert.ErrorIs(t, err, test.Err)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
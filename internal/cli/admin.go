@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/dreamsofcode-io/guestbook/internal/admin"
+)
+
+// adminCmd handles the "admin create-user" command. The password is read
+// from the first line of stdin so it doesn't end up in shell history.
+func adminCmd(ctx context.Context, db *pgxpool.Pool, out io.Writer, args []string) error {
+	if len(args) == 0 || args[0] != "create-user" {
+		return fmt.Errorf("%w: admin requires create-user", ErrUnknownCommand)
+	}
+
+	flags := flag.NewFlagSet("admin create-user", flag.ContinueOnError)
+	flags.SetOutput(out)
+
+	username := flags.String("username", "", "name of the admin to create")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	fmt.Fprint(out, "Password: ")
+
+	password, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return fmt.Errorf("failed to read password: %w", err)
+	}
+
+	user, err := admin.CreateUser(ctx, db, *username, strings.TrimRight(password, "\r\n"))
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "\ncreated admin %s (%s)\n", user.Username, user.ID)
+	return nil
+}
@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/dreamsofcode-io/guestbook/internal/ban"
+)
+
+// banCmd handles the "ban add|list|lift" commands.
+func banCmd(ctx context.Context, db *pgxpool.Pool, out io.Writer, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%w: ban requires add, list or lift", ErrUnknownCommand)
+	}
+
+	bans := ban.New(db)
+
+	flags := flag.NewFlagSet("ban "+args[0], flag.ContinueOnError)
+	flags.SetOutput(out)
+
+	switch args[0] {
+	case "add":
+		reason := flags.String("reason", "", "why the ban was added")
+		by := flags.String("by", os.Getenv("USER"), "name of the person adding the ban")
+		duration := flags.Duration("for", 0, "how long the ban lasts, 0 is permanent")
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+
+		if flags.NArg() != 1 {
+			return fmt.Errorf("ban add requires an ip address or cidr")
+		}
+
+		network, err := ban.ParseNetwork(flags.Arg(0))
+		if err != nil {
+			return err
+		}
+
+		b, err := bans.Add(ctx, network, *reason, *by, *duration)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(out, "banned %s (%s)\n", b.Network, b.ID)
+		return nil
+	case "list":
+		list, err := bans.Active(ctx)
+		if err != nil {
+			return err
+		}
+
+		tw := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "ID\tNETWORK\tEXPIRES\tBY\tREASON")
+		for _, b := range list {
+			expires := "never"
+			if b.ExpiresAt.Valid {
+				expires = b.ExpiresAt.Time.Format("02 Jan 06 15:04 MST")
+			}
+
+			fmt.Fprintf(
+				tw, "%s\t%s\t%s\t%s\t%s\n",
+				b.ID, b.Network, expires, b.CreatedBy, b.Reason,
+			)
+		}
+
+		return tw.Flush()
+	case "lift":
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+
+		if flags.NArg() != 1 {
+			return fmt.Errorf("ban lift requires a ban id")
+		}
+
+		id, err := uuid.Parse(flags.Arg(0))
+		if err != nil {
+			return fmt.Errorf("invalid ban id: %w", err)
+		}
+
+		if err := bans.Lift(ctx, id); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(out, "lifted %s\n", id)
+		return nil
+	default:
+		return fmt.Errorf("%w: ban %s", ErrUnknownCommand, args[0])
+	}
+}
@@ -0,0 +1,51 @@
+// Package cli implements the administrative commands that can be run
+// against the guestbook database from the server binary.
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/dreamsofcode-io/guestbook/internal/database"
+)
+
+var ErrUnknownCommand = errors.New("unknown command")
+
+type command func(ctx context.Context, db *pgxpool.Pool, out io.Writer, args []string) error
+
+var commands = map[string]command{
+	"admin":     adminCmd,
+	"ban":       banCmd,
+	"guestbook": guestbookCmd,
+	"moderate":  moderate,
+}
+
+// Run connects to the database and executes the command named by the first
+// argument, writing any output to out.
+func Run(
+	ctx context.Context, logger *slog.Logger, migrations fs.FS,
+	out io.Writer, args []string,
+) error {
+	if len(args) == 0 {
+		return ErrUnknownCommand
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownCommand, args[0])
+	}
+
+	db, err := database.Connect(ctx, logger, migrations)
+	if err != nil {
+		return fmt.Errorf("failed to connect to db: %w", err)
+	}
+	defer db.Close()
+
+	return cmd(ctx, db, out, args[1:])
+}
@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/dreamsofcode-io/guestbook/internal/books"
+)
+
+// settingsFlags registers a flag for each book setting, defaulting to the
+// given settings.
+func settingsFlags(flags *flag.FlagSet, settings *books.Settings) {
+	flags.StringVar(&settings.Title, "title", settings.Title, "title shown at the top of the book")
+	flags.StringVar(&settings.Description, "description", settings.Description, "description shown below the title")
+	flags.BoolVar(&settings.PreModeration, "pre-moderation", settings.PreModeration, "hold new messages until they are approved")
+	flags.Func("max-length", "longest message allowed, 0 leaves it to the filters", func(s string) error {
+		_, err := fmt.Sscan(s, &settings.MaxLength)
+		return err
+	})
+	flags.BoolVar(&settings.Open, "open", settings.Open, "accept new messages")
+}
+
+// guestbookCmd handles the "guestbook list|create|set" commands.
+func guestbookCmd(ctx context.Context, db *pgxpool.Pool, out io.Writer, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%w: guestbook requires list, create or set", ErrUnknownCommand)
+	}
+
+	store := books.New(db)
+
+	flags := flag.NewFlagSet("guestbook "+args[0], flag.ContinueOnError)
+	flags.SetOutput(out)
+
+	switch args[0] {
+	case "list":
+		list, err := store.List(ctx)
+		if err != nil {
+			return err
+		}
+
+		tw := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "SLUG\tTITLE\tOPEN\tPRE-MODERATION\tMAX LENGTH")
+		for _, b := range list {
+			fmt.Fprintf(
+				tw, "%s\t%s\t%t\t%t\t%d\n",
+				b.Slug, b.Title, b.Open, b.PreModeration, b.MaxLength,
+			)
+		}
+
+		return tw.Flush()
+	case "create":
+		settings := books.Settings{Open: true}
+		settingsFlags(flags, &settings)
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+
+		if flags.NArg() != 1 {
+			return fmt.Errorf("guestbook create requires a slug")
+		}
+
+		b, err := store.Create(ctx, flags.Arg(0), settings)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(out, "created %s (%s)\n", b.Slug, b.ID)
+		return nil
+	case "set":
+		// Flags are applied on top of the current settings, so only the
+		// ones given change.
+		var changes books.Settings
+		settingsFlags(flags, &changes)
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+
+		if flags.NArg() != 1 {
+			return fmt.Errorf("guestbook set requires a slug")
+		}
+
+		b, err := store.Update(ctx, flags.Arg(0), func(settings *books.Settings) {
+			flags.Visit(func(f *flag.Flag) {
+				switch f.Name {
+				case "title":
+					settings.Title = changes.Title
+				case "description":
+					settings.Description = changes.Description
+				case "pre-moderation":
+					settings.PreModeration = changes.PreModeration
+				case "max-length":
+					settings.MaxLength = changes.MaxLength
+				case "open":
+					settings.Open = changes.Open
+				}
+			})
+		})
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(out, "updated %s (%s)\n", b.Slug, b.ID)
+		return nil
+	default:
+		return fmt.Errorf("%w: guestbook %s", ErrUnknownCommand, args[0])
+	}
+}
@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/dreamsofcode-io/guestbook/internal/moderation"
+	"github.com/dreamsofcode-io/guestbook/internal/repository"
+)
+
+// moderate handles the "moderate pending|approve|reject" commands.
+func moderate(ctx context.Context, db *pgxpool.Pool, out io.Writer, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%w: moderate requires pending, approve or reject", ErrUnknownCommand)
+	}
+
+	moderator := moderation.New(db)
+
+	flags := flag.NewFlagSet("moderate "+args[0], flag.ContinueOnError)
+	flags.SetOutput(out)
+
+	switch args[0] {
+	case "pending":
+		limit := flags.Int("limit", 50, "maximum number of entries to list")
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+
+		guests, err := moderator.Pending(ctx, int32(*limit))
+		if err != nil {
+			return err
+		}
+
+		tw := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "ID\tCREATED\tIP\tMESSAGE")
+		for _, g := range guests {
+			fmt.Fprintf(
+				tw, "%s\t%s\t%s\t%s\n",
+				g.ID, g.CreatedAt.Format("02 Jan 06 15:04 MST"), g.Ip, g.Message,
+			)
+		}
+
+		return tw.Flush()
+	case "approve", "reject":
+		by := flags.String("by", os.Getenv("USER"), "name of the moderator")
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+
+		if flags.NArg() != 1 {
+			return fmt.Errorf("moderate %s requires a guest id", args[0])
+		}
+
+		id, err := uuid.Parse(flags.Arg(0))
+		if err != nil {
+			return fmt.Errorf("invalid guest id: %w", err)
+		}
+
+		var guest repository.Guest
+		if args[0] == "approve" {
+			guest, err = moderator.Approve(ctx, id, *by)
+		} else {
+			guest, err = moderator.Reject(ctx, id, *by)
+		}
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(out, "%s %s by %s\n", guest.ID, guest.Status, guest.ModeratedBy.String)
+		return nil
+	default:
+		return fmt.Errorf("%w: moderate %s", ErrUnknownCommand, args[0])
+	}
+}
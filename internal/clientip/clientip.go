@@ -0,0 +1,238 @@
+// Package clientip resolves the address of the client that made a request,
+// taking trusted reverse proxies into account.
+package clientip
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+type (
+	contextKey struct{}
+	secureKey  struct{}
+)
+
+// Headers the forwarding chain can be read from.
+const (
+	HeaderXForwardedFor = "x-forwarded-for"
+	HeaderXRealIP       = "x-real-ip"
+	HeaderForwarded     = "forwarded"
+)
+
+// Resolver determines the client address of a request. Forwarding headers
+// are only believed when they were added by one of the trusted proxies,
+// otherwise anyone could claim to be any address.
+type Resolver struct {
+	trusted []netip.Prefix
+	header  string
+}
+
+// NewResolver creates a resolver that reads the forwarding chain from the
+// named header only. It must be the header the proxies write, as the
+// others are passed through from the client untouched.
+func NewResolver(trusted []netip.Prefix, header string) *Resolver {
+	return &Resolver{
+		trusted: trusted,
+		header:  header,
+	}
+}
+
+func (r *Resolver) isTrusted(addr netip.Addr) bool {
+	for _, prefix := range r.trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Resolve returns the client address of the request. The forwarding chain
+// is walked from the nearest hop outwards, and the first address that
+// isn't a trusted proxy is the client.
+func (r *Resolver) Resolve(req *http.Request) netip.Addr {
+	peer := remoteAddr(req)
+	if !peer.IsValid() || !r.isTrusted(peer) {
+		return peer
+	}
+
+	var hops []netip.Addr
+	switch r.header {
+	case HeaderForwarded:
+		hops = forwardedFor(req.Header)
+	case HeaderXRealIP:
+		if addr := parseAddr(req.Header.Get("X-Real-IP")); addr.IsValid() {
+			hops = []netip.Addr{addr}
+		}
+	default:
+		hops = forwardedList(req.Header.Values("X-Forwarded-For"))
+	}
+
+	client := peer
+	for i := len(hops) - 1; i >= 0; i-- {
+		// An unparseable hop can't be trusted, so stop at the last
+		// proxy that we know about.
+		if !hops[i].IsValid() {
+			break
+		}
+
+		client = hops[i]
+		if !r.isTrusted(client) {
+			break
+		}
+	}
+
+	return client
+}
+
+// secure reports whether the client used https, either directly or to a
+// trusted proxy that says so in X-Forwarded-Proto.
+func (r *Resolver) secure(req *http.Request) bool {
+	if req.TLS != nil {
+		return true
+	}
+
+	peer := remoteAddr(req)
+
+	return peer.IsValid() && r.isTrusted(peer) &&
+		req.Header.Get("X-Forwarded-Proto") == "https"
+}
+
+// Middleware resolves the client address and whether it used https once,
+// and stores them in the request context for every handler further down
+// the chain.
+func (r *Resolver) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ctx := context.WithValue(req.Context(), contextKey{}, r.Resolve(req))
+		ctx = context.WithValue(ctx, secureKey{}, r.secure(req))
+		next.ServeHTTP(w, req.WithContext(ctx))
+	})
+}
+
+// Secure reports whether the client made the request over https, such as
+// to decide whether cookies can be marked secure. Behind a proxy this comes
+// from X-Forwarded-Proto, which is only believed from trusted proxies. When
+// the middleware hasn't run only the connection itself is looked at.
+func Secure(req *http.Request) bool {
+	if secure, ok := req.Context().Value(secureKey{}).(bool); ok {
+		return secure
+	}
+
+	return req.TLS != nil
+}
+
+// FromContext returns the client address stored by the middleware.
+func FromContext(ctx context.Context) (netip.Addr, bool) {
+	addr, ok := ctx.Value(contextKey{}).(netip.Addr)
+	return addr, ok
+}
+
+// FromRequest returns the resolved client address of the request, falling
+// back to the remote address when the middleware hasn't run.
+func FromRequest(req *http.Request) netip.Addr {
+	if addr, ok := FromContext(req.Context()); ok {
+		return addr
+	}
+
+	return remoteAddr(req)
+}
+
+// IP converts an address into a net.IP, unmapping IPv4 in IPv6 addresses.
+// An invalid address becomes nil.
+func IP(addr netip.Addr) net.IP {
+	if !addr.IsValid() {
+		return nil
+	}
+
+	return net.IP(addr.Unmap().AsSlice())
+}
+
+// Subnet returns the part of an address that identifies a single client:
+// the whole of an IPv4 address, or the /64 network of an IPv6 address since
+// clients are usually free to pick any address within it.
+func Subnet(ip net.IP) net.IP {
+	if v4 := ip.To4(); v4 != nil {
+		return v4
+	}
+
+	if len(ip) == net.IPv6len {
+		return ip.Mask(net.CIDRMask(64, 128))
+	}
+
+	return ip
+}
+
+func remoteAddr(req *http.Request) netip.Addr {
+	addrPort, err := netip.ParseAddrPort(req.RemoteAddr)
+	if err == nil {
+		return addrPort.Addr().Unmap()
+	}
+
+	return parseAddr(req.RemoteAddr)
+}
+
+// parseAddr parses an address that may have a port and may be wrapped in
+// brackets or quotes.
+func parseAddr(s string) netip.Addr {
+	s = strings.Trim(strings.TrimSpace(s), `"`)
+	if s == "" {
+		return netip.Addr{}
+	}
+
+	if addrPort, err := netip.ParseAddrPort(s); err == nil {
+		return addrPort.Addr().Unmap()
+	}
+
+	addr, err := netip.ParseAddr(strings.Trim(s, "[]"))
+	if err != nil {
+		return netip.Addr{}
+	}
+
+	return addr.Unmap()
+}
+
+// forwardedList parses the comma separated addresses of X-Forwarded-For
+// headers, in order. Invalid entries are kept as invalid addresses.
+func forwardedList(values []string) []netip.Addr {
+	var res []netip.Addr
+
+	for _, value := range values {
+		for _, part := range strings.Split(value, ",") {
+			res = append(res, parseAddr(part))
+		}
+	}
+
+	return res
+}
+
+// forwardedFor parses the for parameters of RFC 7239 Forwarded headers, in
+// order. Obfuscated identifiers and "unknown" become invalid addresses.
+func forwardedFor(header http.Header) []netip.Addr {
+	var res []netip.Addr
+
+	for _, value := range header.Values("Forwarded") {
+		for _, element := range strings.Split(value, ",") {
+			found := false
+
+			for _, pair := range strings.Split(element, ";") {
+				key, val, ok := strings.Cut(strings.TrimSpace(pair), "=")
+				if !ok || !strings.EqualFold(key, "for") {
+					continue
+				}
+
+				res = append(res, parseAddr(val))
+				found = true
+				break
+			}
+
+			if !found {
+				res = append(res, netip.Addr{})
+			}
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,186 @@
+package clientip_test
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dreamsofcode-io/guestbook/internal/clientip"
+)
+
+func TestResolve(t *testing.T) {
+	trusted := []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("2001:db8:ffff::/48"),
+	}
+
+	testCases := []struct {
+		Description string
+		// Header is the one the proxy writes, X-Forwarded-For if empty.
+		Header     string
+		RemoteAddr string
+		Headers    map[string]string
+		Expected   string
+	}{
+		{
+			Description: "untrusted peer ignores headers",
+			RemoteAddr:  "203.0.113.5:4000",
+			Headers:     map[string]string{"X-Forwarded-For": "198.51.100.1"},
+			Expected:    "203.0.113.5",
+		},
+		{
+			Description: "trusted peer without headers",
+			RemoteAddr:  "10.0.0.2:4000",
+			Expected:    "10.0.0.2",
+		},
+		{
+			Description: "x-forwarded-for through trusted proxy",
+			RemoteAddr:  "10.0.0.2:4000",
+			Headers:     map[string]string{"X-Forwarded-For": "198.51.100.1"},
+			Expected:    "198.51.100.1",
+		},
+		{
+			Description: "spoofed x-forwarded-for entry is skipped",
+			RemoteAddr:  "10.0.0.2:4000",
+			Headers:     map[string]string{"X-Forwarded-For": "1.2.3.4, 198.51.100.1, 10.0.0.9"},
+			Expected:    "198.51.100.1",
+		},
+		{
+			Description: "x-real-ip through trusted proxy",
+			Header:      clientip.HeaderXRealIP,
+			RemoteAddr:  "10.0.0.2:4000",
+			Headers:     map[string]string{"X-Real-IP": "198.51.100.1"},
+			Expected:    "198.51.100.1",
+		},
+		{
+			Description: "forwarded through trusted proxy",
+			Header:      clientip.HeaderForwarded,
+			RemoteAddr:  "10.0.0.2:4000",
+			Headers: map[string]string{
+				"Forwarded":       `for="[2001:db8:cafe::17]:4711";proto=https, for=10.0.0.3`,
+				"X-Forwarded-For": "198.51.100.1",
+			},
+			Expected: "2001:db8:cafe::17",
+		},
+		{
+			Description: "forwarded with unknown hop stops at last proxy",
+			Header:      clientip.HeaderForwarded,
+			RemoteAddr:  "10.0.0.2:4000",
+			Headers:     map[string]string{"Forwarded": "for=unknown, for=10.0.0.3"},
+			Expected:    "10.0.0.3",
+		},
+		{
+			Description: "forwarded sent by the client is ignored",
+			RemoteAddr:  "10.0.0.2:4000",
+			Headers: map[string]string{
+				"Forwarded":       "for=1.2.3.4",
+				"X-Forwarded-For": "198.51.100.1",
+			},
+			Expected: "198.51.100.1",
+		},
+		{
+			Description: "x-real-ip sent by the client is ignored",
+			RemoteAddr:  "10.0.0.2:4000",
+			Headers:     map[string]string{"X-Real-IP": "1.2.3.4"},
+			Expected:    "10.0.0.2",
+		},
+		{
+			Description: "ipv6 peer",
+			RemoteAddr:  "[2001:db8::1]:4000",
+			Expected:    "2001:db8::1",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.Description, func(t *testing.T) {
+			header := test.Header
+			if header == "" {
+				header = clientip.HeaderXForwardedFor
+			}
+
+			resolver := clientip.NewResolver(trusted, header)
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = test.RemoteAddr
+			for key, value := range test.Headers {
+				req.Header.Set(key, value)
+			}
+
+			assert.Equal(t, test.Expected, resolver.Resolve(req).String())
+		})
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	resolver := clientip.NewResolver(nil, clientip.HeaderXForwardedFor)
+
+	var got netip.Addr
+	handler := resolver.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = clientip.FromRequest(r)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "192.0.2.7:1234"
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, "192.0.2.7", got.String())
+}
+
+func TestSubnet(t *testing.T) {
+	assert.Equal(t, "192.0.2.7", clientip.Subnet(net.ParseIP("::ffff:192.0.2.7")).String())
+	assert.Equal(t, "2001:db8:1:2::", clientip.Subnet(net.ParseIP("2001:db8:1:2:3:4:5:6")).String())
+	assert.Nil(t, clientip.Subnet(nil))
+}
+
+func TestSecure(t *testing.T) {
+	resolver := clientip.NewResolver(
+		[]netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+		clientip.HeaderXForwardedFor,
+	)
+
+	testCases := []struct {
+		Description string
+		RemoteAddr  string
+		Proto       string
+		Expected    bool
+	}{
+		{
+			Description: "plain http",
+			RemoteAddr:  "203.0.113.5:4000",
+		},
+		{
+			Description: "https through trusted proxy",
+			RemoteAddr:  "10.0.0.2:4000",
+			Proto:       "https",
+			Expected:    true,
+		},
+		{
+			Description: "x-forwarded-proto from untrusted peer is ignored",
+			RemoteAddr:  "203.0.113.5:4000",
+			Proto:       "https",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.Description, func(t *testing.T) {
+			var got bool
+			handler := resolver.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = clientip.Secure(r)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = test.RemoteAddr
+			if test.Proto != "" {
+				req.Header.Set("X-Forwarded-Proto", test.Proto)
+			}
+
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.Equal(t, test.Expected, got)
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+const defaultSessionTTL = 12 * time.Hour
+
+// Admin holds the configuration for the admin area.
+type Admin struct {
+	SessionTTL time.Duration
+}
+
+// NewAdmin creates an admin configuration from the ADMIN_SESSION_TTL
+// environment variable, defaulting to 12 hours when it is not set.
+func NewAdmin() (*Admin, error) {
+	config := &Admin{
+		SessionTTL: defaultSessionTTL,
+	}
+
+	value, ok := os.LookupEnv("ADMIN_SESSION_TTL")
+	if !ok {
+		return config, nil
+	}
+
+	ttl, err := time.ParseDuration(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ADMIN_SESSION_TTL: %w", err)
+	}
+
+	if ttl <= 0 {
+		return nil, fmt.Errorf("invalid session ttl")
+	}
+
+	config.SessionTTL = ttl
+
+	return config, nil
+}
@@ -0,0 +1,47 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	defaultEditWindow = 15 * time.Minute
+	maxEditWindow     = 7 * 24 * time.Hour
+)
+
+// Editing holds the configuration for authors changing their own entries.
+type Editing struct {
+	// Window is how long after posting an author may edit or delete their
+	// entry. Zero turns editing off.
+	Window time.Duration
+}
+
+// NewEditing creates an editing configuration from the EDIT_WINDOW
+// environment variable.
+func NewEditing() (*Editing, error) {
+	window, err := lookupDuration("EDIT_WINDOW", defaultEditWindow)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &Editing{
+		Window: window,
+	}
+
+	err = config.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("failed to validate config: %w", err)
+	}
+
+	return config, nil
+}
+
+// Validate checks the window is within range.
+func (c *Editing) Validate() error {
+	if c.Window < 0 || c.Window > maxEditWindow {
+		return fmt.Errorf("edit window must be between 0 and %s", maxEditWindow)
+	}
+
+	return nil
+}
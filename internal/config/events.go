@@ -0,0 +1,60 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	defaultHeartbeat    = 15 * time.Second
+	defaultMaxPerClient = 4
+)
+
+// Events holds the configuration for the live event stream.
+type Events struct {
+	// Heartbeat is how often a comment is sent to keep idle connections
+	// open through proxies.
+	Heartbeat time.Duration
+	// MaxPerClient is how many streams a single client IP may have open.
+	MaxPerClient int
+}
+
+// NewEvents creates an events configuration from the EVENTS_HEARTBEAT and
+// EVENTS_MAX_PER_CLIENT environment variables.
+func NewEvents() (*Events, error) {
+	heartbeat, err := lookupDuration("EVENTS_HEARTBEAT", defaultHeartbeat)
+	if err != nil {
+		return nil, err
+	}
+
+	maxPerClient, err := lookupInt("EVENTS_MAX_PER_CLIENT", defaultMaxPerClient)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &Events{
+		Heartbeat:    heartbeat,
+		MaxPerClient: maxPerClient,
+	}
+
+	err = config.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("failed to validate config: %w", err)
+	}
+
+	return config, nil
+}
+
+// Validate checks the heartbeat is positive and the connection cap isn't
+// negative.
+func (c *Events) Validate() error {
+	if c.Heartbeat <= 0 {
+		return fmt.Errorf("invalid heartbeat")
+	}
+
+	if c.MaxPerClient < 0 {
+		return fmt.Errorf("invalid max connections per client")
+	}
+
+	return nil
+}
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+const (
+	defaultFeedSize = 50
+	maxFeedSize     = 500
+)
+
+// Feed holds the configuration for the RSS and Atom feeds.
+type Feed struct {
+	// BaseURL is the public address of the guestbook, used for the absolute
+	// links feeds require. When empty it is worked out from each request.
+	BaseURL string
+	// Size is how many of the newest entries are included.
+	Size int32
+}
+
+// NewFeed creates a feed configuration from the PUBLIC_URL and FEED_SIZE
+// environment variables.
+func NewFeed() (*Feed, error) {
+	size, err := lookupInt32("FEED_SIZE", defaultFeedSize)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &Feed{
+		BaseURL: strings.TrimSuffix(os.Getenv("PUBLIC_URL"), "/"),
+		Size:    size,
+	}
+
+	err = config.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("failed to validate config: %w", err)
+	}
+
+	return config, nil
+}
+
+// Validate checks the base URL is absolute and the size is within range.
+func (c *Feed) Validate() error {
+	if c.BaseURL != "" {
+		u, err := url.Parse(c.BaseURL)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("invalid PUBLIC_URL %q", c.BaseURL)
+		}
+	}
+
+	if c.Size <= 0 || c.Size > maxFeedSize {
+		return fmt.Errorf("feed size must be between 1 and %d", maxFeedSize)
+	}
+
+	return nil
+}
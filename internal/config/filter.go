@@ -0,0 +1,102 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/dreamsofcode-io/guestbook/internal/books"
+)
+
+var defaultFilters = []string{"max_length", "profanity", "link_count"}
+
+// Filters holds the configuration for the content filter pipeline.
+type Filters struct {
+	// Order lists the enabled filters by name, in the order they run.
+	Order []string
+	// MaxLength is the longest message the max_length filter allows, at
+	// most what the database stores.
+	MaxLength int
+	// MaxLinks is the number of links above which messages are held.
+	MaxLinks int
+	// CensorProfanity rewrites profane words instead of rejecting.
+	CensorProfanity bool
+	// DenylistFile is a file of regular expressions for the denylist.
+	DenylistFile string
+}
+
+func lookupInt(key string, fallback int) (int, error) {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return fallback, nil
+	}
+
+	res, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse %s: %w", key, err)
+	}
+
+	return res, nil
+}
+
+// NewFilters creates a filter configuration from the CONTENT_FILTERS,
+// FILTER_MAX_LENGTH, FILTER_MAX_LINKS, FILTER_CENSOR_PROFANITY and
+// FILTER_DENYLIST_FILE environment variables.
+func NewFilters() (*Filters, error) {
+	order := defaultFilters
+	if value, ok := os.LookupEnv("CONTENT_FILTERS"); ok {
+		order = nil
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				order = append(order, name)
+			}
+		}
+	}
+
+	maxLength, err := lookupInt("FILTER_MAX_LENGTH", books.MaxMessageLength)
+	if err != nil {
+		return nil, err
+	}
+
+	maxLinks, err := lookupInt("FILTER_MAX_LINKS", 2)
+	if err != nil {
+		return nil, err
+	}
+
+	censor := false
+	if value, ok := os.LookupEnv("FILTER_CENSOR_PROFANITY"); ok {
+		censor, err = strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse FILTER_CENSOR_PROFANITY: %w", err)
+		}
+	}
+
+	config := &Filters{
+		Order:           order,
+		MaxLength:       maxLength,
+		MaxLinks:        maxLinks,
+		CensorProfanity: censor,
+		DenylistFile:    os.Getenv("FILTER_DENYLIST_FILE"),
+	}
+
+	err = config.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("failed to validate config: %w", err)
+	}
+
+	return config, nil
+}
+
+// Validate checks the filter limits are usable.
+func (c *Filters) Validate() error {
+	if c.MaxLength <= 0 || c.MaxLength > books.MaxMessageLength {
+		return fmt.Errorf("max length must be between 1 and %d", books.MaxMessageLength)
+	}
+
+	if c.MaxLinks < 0 {
+		return fmt.Errorf("invalid max links")
+	}
+
+	return nil
+}
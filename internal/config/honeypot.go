@@ -0,0 +1,62 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	defaultMinFillTime = 2 * time.Second
+	defaultFormMaxAge  = 2 * time.Hour
+	maxMinFillTime     = time.Minute
+	maxFormMaxAge      = 7 * 24 * time.Hour
+)
+
+// Honeypot holds the configuration for spotting bots by how they fill in
+// the form.
+type Honeypot struct {
+	// MinFillTime is how long after the form was shown a person could
+	// plausibly post it. Zero turns the check off.
+	MinFillTime time.Duration
+	// MaxAge is how long a form can be posted after it was shown.
+	MaxAge time.Duration
+}
+
+// NewHoneypot creates a honeypot configuration from the
+// HONEYPOT_MIN_FILL_TIME and HONEYPOT_MAX_AGE environment variables.
+func NewHoneypot() (*Honeypot, error) {
+	minFillTime, err := lookupDuration("HONEYPOT_MIN_FILL_TIME", defaultMinFillTime)
+	if err != nil {
+		return nil, err
+	}
+
+	maxAge, err := lookupDuration("HONEYPOT_MAX_AGE", defaultFormMaxAge)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &Honeypot{
+		MinFillTime: minFillTime,
+		MaxAge:      maxAge,
+	}
+
+	err = config.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("failed to validate config: %w", err)
+	}
+
+	return config, nil
+}
+
+// Validate checks the durations are within range.
+func (c *Honeypot) Validate() error {
+	if c.MinFillTime < 0 || c.MinFillTime > maxMinFillTime {
+		return fmt.Errorf("honeypot min fill time must be between 0 and %s", maxMinFillTime)
+	}
+
+	if c.MaxAge < time.Minute || c.MaxAge > maxFormMaxAge {
+		return fmt.Errorf("honeypot max age must be between 1m and %s", maxFormMaxAge)
+	}
+
+	return nil
+}
@@ -0,0 +1,34 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// Markdown holds the configuration for how messages are formatted.
+type Markdown struct {
+	// Enabled renders the Markdown subset in messages. When disabled,
+	// messages are shown as plain text.
+	Enabled bool
+}
+
+// NewMarkdown creates a Markdown configuration from the MARKDOWN environment
+// variable. Markdown is disabled when it is not set.
+func NewMarkdown() (*Markdown, error) {
+	config := &Markdown{}
+
+	value, ok := os.LookupEnv("MARKDOWN")
+	if !ok {
+		return config, nil
+	}
+
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse MARKDOWN: %w", err)
+	}
+
+	config.Enabled = enabled
+
+	return config, nil
+}
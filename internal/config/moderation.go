@@ -0,0 +1,34 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// Moderation holds the configuration for how new messages are moderated.
+type Moderation struct {
+	// PreModeration holds new messages as pending until a moderator has
+	// approved them. When disabled, messages are published immediately.
+	PreModeration bool
+}
+
+// NewModeration creates a moderation configuration from the PRE_MODERATION
+// environment variable. Pre-moderation is disabled when it is not set.
+func NewModeration() (*Moderation, error) {
+	config := &Moderation{}
+
+	value, ok := os.LookupEnv("PRE_MODERATION")
+	if !ok {
+		return config, nil
+	}
+
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse PRE_MODERATION: %w", err)
+	}
+
+	config.PreModeration = enabled
+
+	return config, nil
+}
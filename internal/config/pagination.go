@@ -0,0 +1,88 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+const (
+	defaultPageSize = 50
+	defaultMaxPage  = 200
+
+	// pageSizeCeiling is the hard upper bound for any configured page size.
+	pageSizeCeiling = 1000
+)
+
+// Pagination holds the configuration for how many guest entries are
+// returned per page.
+type Pagination struct {
+	DefaultSize int32
+	MaxSize     int32
+}
+
+func lookupInt32(key string, fallback int32) (int32, error) {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return fallback, nil
+	}
+
+	res, err := strconv.ParseInt(value, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse %s: %w", key, err)
+	}
+
+	return int32(res), nil
+}
+
+// NewPagination creates a pagination configuration from the PAGE_SIZE and
+// PAGE_SIZE_MAX environment variables, falling back to defaults when they
+// are not set.
+func NewPagination() (*Pagination, error) {
+	size, err := lookupInt32("PAGE_SIZE", defaultPageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	max, err := lookupInt32("PAGE_SIZE_MAX", defaultMaxPage)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &Pagination{
+		DefaultSize: size,
+		MaxSize:     max,
+	}
+
+	err = config.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("failed to validate config: %w", err)
+	}
+
+	return config, nil
+}
+
+// Validate checks that the page sizes are positive, consistent with each
+// other and below the hard ceiling.
+func (c *Pagination) Validate() error {
+	if c.DefaultSize <= 0 {
+		return fmt.Errorf("invalid default page size")
+	}
+
+	if c.MaxSize < c.DefaultSize || c.MaxSize > pageSizeCeiling {
+		return fmt.Errorf("invalid max page size")
+	}
+
+	return nil
+}
+
+// Size resolves the page size for a request. An empty or invalid value
+// falls back to the default and anything above the maximum is clamped.
+func (c *Pagination) Size(requested string) int32 {
+	size, err := strconv.ParseInt(requested, 10, 32)
+	if err != nil || size <= 0 {
+		return c.DefaultSize
+	}
+
+	return min(int32(size), c.MaxSize)
+}
@@ -0,0 +1,145 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Failure policies decide whether a solved challenge is accepted while
+// redis, which tracks used challenges, is unavailable.
+const (
+	PowFailOpen   = "open"
+	PowFailClosed = "closed"
+)
+
+const (
+	defaultPowDifficulty    = 16
+	defaultPowMaxDifficulty = 22
+	defaultPowSurgeRate     = 20
+	defaultPowTTL           = 10 * time.Minute
+	defaultPowTimeout       = 100 * time.Millisecond
+	maxPowDifficulty        = 32
+	maxPowTTL               = 24 * time.Hour
+)
+
+// ProofOfWork holds the configuration for the challenge browsers solve
+// before posting through the form.
+type ProofOfWork struct {
+	// Difficulty is the number of leading zero bits a solution's hash needs
+	// while posting is quiet. Zero turns the challenge off.
+	Difficulty int
+	// MaxDifficulty caps how far the difficulty rises during a surge.
+	MaxDifficulty int
+	// SurgeRate is the number of posts per minute above which the
+	// difficulty rises, by one bit each time the rate doubles.
+	SurgeRate int
+	// TTL is how long a challenge can be solved and used for.
+	TTL time.Duration
+	// Failure is what happens to solved challenges while redis is
+	// unavailable: accept them without checking they haven't been used
+	// ("open"), or refuse to post ("closed").
+	Failure string
+	// Timeout bounds each call to redis.
+	Timeout time.Duration
+	// API makes messages sent through the JSON API and websocket solve a
+	// challenge too.
+	API bool
+}
+
+// NewProofOfWork creates a proof of work configuration from the
+// POW_DIFFICULTY, POW_MAX_DIFFICULTY, POW_SURGE_RATE and POW_TTL
+// environment variables. How redis failures are handled comes from
+// POW_FAILURE and POW_TIMEOUT, and whether the JSON API and websocket need
+// a challenge from POW_API.
+func NewProofOfWork() (*ProofOfWork, error) {
+	difficulty, err := lookupInt("POW_DIFFICULTY", defaultPowDifficulty)
+	if err != nil {
+		return nil, err
+	}
+
+	maxDifficulty, err := lookupInt("POW_MAX_DIFFICULTY", defaultPowMaxDifficulty)
+	if err != nil {
+		return nil, err
+	}
+
+	surgeRate, err := lookupInt("POW_SURGE_RATE", defaultPowSurgeRate)
+	if err != nil {
+		return nil, err
+	}
+
+	ttl, err := lookupDuration("POW_TTL", defaultPowTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	failure, ok := os.LookupEnv("POW_FAILURE")
+	if !ok {
+		failure = PowFailOpen
+	}
+
+	timeout, err := lookupDuration("POW_TIMEOUT", defaultPowTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	var api bool
+	if value, ok := os.LookupEnv("POW_API"); ok {
+		api, err = strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse POW_API: %w", err)
+		}
+	}
+
+	config := &ProofOfWork{
+		Difficulty:    difficulty,
+		MaxDifficulty: max(maxDifficulty, difficulty),
+		SurgeRate:     surgeRate,
+		TTL:           ttl,
+		Failure:       failure,
+		Timeout:       timeout,
+		API:           api,
+	}
+
+	err = config.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("failed to validate config: %w", err)
+	}
+
+	return config, nil
+}
+
+// Validate checks the difficulties, surge rate and TTL are within range and
+// the failure policy exists.
+func (c *ProofOfWork) Validate() error {
+	if c.Difficulty < 0 || c.Difficulty > maxPowDifficulty {
+		return fmt.Errorf("pow difficulty must be between 0 and %d", maxPowDifficulty)
+	}
+
+	if c.MaxDifficulty < c.Difficulty || c.MaxDifficulty > maxPowDifficulty {
+		return fmt.Errorf(
+			"pow max difficulty must be between the difficulty and %d", maxPowDifficulty,
+		)
+	}
+
+	if c.SurgeRate < 1 {
+		return fmt.Errorf("pow surge rate must be at least 1")
+	}
+
+	if c.TTL < time.Minute || c.TTL > maxPowTTL {
+		return fmt.Errorf("pow ttl must be between 1m and %s", maxPowTTL)
+	}
+
+	switch c.Failure {
+	case PowFailOpen, PowFailClosed:
+	default:
+		return fmt.Errorf("invalid pow failure policy %q", c.Failure)
+	}
+
+	if c.Timeout < 0 {
+		return fmt.Errorf("pow timeout can't be negative")
+	}
+
+	return nil
+}
@@ -0,0 +1,77 @@
+package config
+
+import (
+	"fmt"
+	"net/netip"
+	"os"
+	"strings"
+
+	"github.com/dreamsofcode-io/guestbook/internal/clientip"
+)
+
+// Proxy holds the configuration for the reverse proxies in front of the
+// application.
+type Proxy struct {
+	// Trusted lists the networks whose forwarding headers are believed.
+	Trusted []netip.Prefix
+	// Header is the one header the proxies write the client address to.
+	// Any other forwarding header came from the client.
+	Header string
+}
+
+// NewProxy creates a proxy configuration from the TRUSTED_PROXIES
+// environment variable, a comma separated list of IP addresses and CIDR
+// ranges, and TRUSTED_PROXY_HEADER. No proxies are trusted when it is not
+// set, and the header defaults to X-Forwarded-For.
+func NewProxy() (*Proxy, error) {
+	header, ok := os.LookupEnv("TRUSTED_PROXY_HEADER")
+	if !ok {
+		header = clientip.HeaderXForwardedFor
+	}
+
+	config := &Proxy{
+		Header: strings.ToLower(strings.TrimSpace(header)),
+	}
+
+	for _, value := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+
+		if !strings.Contains(value, "/") {
+			addr, err := netip.ParseAddr(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q: %w", value, err)
+			}
+
+			config.Trusted = append(config.Trusted, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", value, err)
+		}
+
+		config.Trusted = append(config.Trusted, prefix.Masked())
+	}
+
+	err := config.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("failed to validate config: %w", err)
+	}
+
+	return config, nil
+}
+
+// Validate checks the header is one the client address can be read from.
+func (c *Proxy) Validate() error {
+	switch c.Header {
+	case clientip.HeaderXForwardedFor, clientip.HeaderXRealIP, clientip.HeaderForwarded:
+	default:
+		return fmt.Errorf("invalid trusted proxy header %q", c.Header)
+	}
+
+	return nil
+}
@@ -0,0 +1,272 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	RateLimitStoreRedis  = "redis"
+	RateLimitStoreMemory = "memory"
+)
+
+// Failure policies decide what happens to requests while the redis store is
+// unavailable.
+const (
+	RateLimitFailOpen     = "open"
+	RateLimitFailClosed   = "closed"
+	RateLimitFailFallback = "memory"
+)
+
+const (
+	defaultRateLimitTimeout = 100 * time.Millisecond
+	defaultBreakerThreshold = 5
+	defaultBreakerCooldown  = 30 * time.Second
+)
+
+const (
+	RateLimitKeyIP      = "ip"
+	RateLimitKeyAPIKey  = "api_key"
+	RateLimitKeySession = "session"
+)
+
+// Duration is a time.Duration that is written as a string such as "1m" in
+// JSON.
+type Duration struct {
+	time.Duration
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("duration must be a string: %w", err)
+	}
+
+	duration, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+
+	d.Duration = duration
+
+	return nil
+}
+
+// RateLimitPolicy describes the limit applied to a group of routes.
+type RateLimitPolicy struct {
+	Algorithm string   `json:"algorithm"`
+	Rate      int64    `json:"rate"`
+	Period    Duration `json:"period"`
+	Burst     int64    `json:"burst,omitempty"`
+	// Key is what requests are counted against: "ip", "api_key" or
+	// "session".
+	Key      string `json:"key"`
+	Disabled bool   `json:"disabled,omitempty"`
+}
+
+// defaultPolicies are applied unless they are overridden by name.
+var defaultPolicies = map[string]RateLimitPolicy{
+	"home": {
+		Algorithm: "token_bucket", Rate: 120, Period: Duration{time.Minute},
+		Burst: 60, Key: RateLimitKeyIP,
+	},
+	"post": {
+		Algorithm: "gcra", Rate: 1, Period: Duration{time.Minute},
+		Key: RateLimitKeyIP,
+	},
+	"react": {
+		Algorithm: "token_bucket", Rate: 30, Period: Duration{time.Minute},
+		Burst: 10, Key: RateLimitKeyIP,
+	},
+	"edit": {
+		Algorithm: "token_bucket", Rate: 10, Period: Duration{time.Minute},
+		Burst: 5, Key: RateLimitKeyIP,
+	},
+	"events": {
+		Algorithm: "token_bucket", Rate: 30, Period: Duration{time.Minute},
+		Burst: 10, Key: RateLimitKeyIP,
+	},
+	"api": {
+		Algorithm: "token_bucket", Rate: 60, Period: Duration{time.Minute},
+		Burst: 30, Key: RateLimitKeyAPIKey,
+	},
+	"api_write": {
+		Algorithm: "gcra", Rate: 1, Period: Duration{time.Minute},
+		Key: RateLimitKeyAPIKey,
+	},
+	"admin": {
+		Algorithm: "sliding_log", Rate: 300, Period: Duration{time.Minute},
+		Key: RateLimitKeySession,
+	},
+	"admin_login": {
+		Algorithm: "sliding_log", Rate: 10, Period: Duration{15 * time.Minute},
+		Key: RateLimitKeyIP,
+	},
+}
+
+// RateLimit holds the configuration for rate limiting.
+type RateLimit struct {
+	// Store is where rate limit events are kept, either "redis" to share
+	// limits between replicas or "memory" for a single instance.
+	Store string
+	// Policies are the limits applied to each group of routes, by name.
+	Policies map[string]RateLimitPolicy
+	// Failure is what happens while redis is unavailable: let requests
+	// through ("open"), reject them ("closed") or limit them in process
+	// ("memory").
+	Failure string
+	// Timeout bounds each call to redis.
+	Timeout time.Duration
+	// BreakerThreshold is how many consecutive redis failures open the
+	// circuit breaker, and BreakerCooldown how long it stays open before
+	// redis is tried again.
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+}
+
+func lookupDuration(key string, fallback time.Duration) (time.Duration, error) {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return fallback, nil
+	}
+
+	res, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse %s: %w", key, err)
+	}
+
+	return res, nil
+}
+
+// loadPolicies reads policy overrides from the RATE_LIMIT_POLICIES env
+// variable, or the file named by RATE_LIMIT_POLICIES_FILE. Both hold a JSON
+// object of policies by name.
+func loadPolicies() (map[string]RateLimitPolicy, error) {
+	policies := map[string]RateLimitPolicy{}
+	for name, policy := range defaultPolicies {
+		policies[name] = policy
+	}
+
+	data, ok := os.LookupEnv("RATE_LIMIT_POLICIES")
+	if path, hasFile := os.LookupEnv("RATE_LIMIT_POLICIES_FILE"); !ok && hasFile {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read policies file: %w", err)
+		}
+
+		data, ok = string(raw), true
+	}
+
+	if !ok || strings.TrimSpace(data) == "" {
+		return policies, nil
+	}
+
+	overrides := map[string]RateLimitPolicy{}
+	if err := json.Unmarshal([]byte(data), &overrides); err != nil {
+		return nil, fmt.Errorf("failed to parse policies: %w", err)
+	}
+
+	for name, policy := range overrides {
+		policies[name] = policy
+	}
+
+	return policies, nil
+}
+
+// NewRateLimit creates a rate limit configuration from the RATE_LIMIT_STORE
+// environment variable, defaulting to redis when it is not set, and the
+// policies from RATE_LIMIT_POLICIES or RATE_LIMIT_POLICIES_FILE. How redis
+// failures are handled comes from RATE_LIMIT_FAILURE, RATE_LIMIT_TIMEOUT,
+// RATE_LIMIT_BREAKER_THRESHOLD and RATE_LIMIT_BREAKER_COOLDOWN.
+func NewRateLimit() (*RateLimit, error) {
+	store, ok := os.LookupEnv("RATE_LIMIT_STORE")
+	if !ok {
+		store = RateLimitStoreRedis
+	}
+
+	failure, ok := os.LookupEnv("RATE_LIMIT_FAILURE")
+	if !ok {
+		failure = RateLimitFailFallback
+	}
+
+	policies, err := loadPolicies()
+	if err != nil {
+		return nil, err
+	}
+
+	timeout, err := lookupDuration("RATE_LIMIT_TIMEOUT", defaultRateLimitTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	threshold, err := lookupInt("RATE_LIMIT_BREAKER_THRESHOLD", defaultBreakerThreshold)
+	if err != nil {
+		return nil, err
+	}
+
+	cooldown, err := lookupDuration("RATE_LIMIT_BREAKER_COOLDOWN", defaultBreakerCooldown)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &RateLimit{
+		Store:            store,
+		Policies:         policies,
+		Failure:          failure,
+		Timeout:          timeout,
+		BreakerThreshold: threshold,
+		BreakerCooldown:  cooldown,
+	}
+
+	err = config.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("failed to validate config: %w", err)
+	}
+
+	return config, nil
+}
+
+// Validate checks the rate limit store and failure policy are ones that
+// exist and that every enabled policy is usable.
+func (c *RateLimit) Validate() error {
+	switch c.Store {
+	case RateLimitStoreRedis, RateLimitStoreMemory:
+	default:
+		return fmt.Errorf("invalid rate limit store %q", c.Store)
+	}
+
+	switch c.Failure {
+	case RateLimitFailOpen, RateLimitFailClosed, RateLimitFailFallback:
+	default:
+		return fmt.Errorf("invalid rate limit failure policy %q", c.Failure)
+	}
+
+	if c.Timeout < 0 || c.BreakerThreshold < 1 || c.BreakerCooldown <= 0 {
+		return fmt.Errorf("invalid rate limit circuit breaker")
+	}
+
+	for name, policy := range c.Policies {
+		if policy.Disabled {
+			continue
+		}
+
+		if policy.Rate <= 0 || policy.Period.Duration <= 0 || policy.Burst < 0 {
+			return fmt.Errorf("invalid limit for policy %q", name)
+		}
+
+		switch policy.Key {
+		case RateLimitKeyIP, RateLimitKeyAPIKey, RateLimitKeySession:
+		default:
+			return fmt.Errorf("invalid key %q for policy %q", policy.Key, name)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,141 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dreamsofcode-io/guestbook/internal/config"
+)
+
+func TestRateLimitPolicies(t *testing.T) {
+	testCases := []struct {
+		Description string
+		Env         map[string]string
+		Check       func(t *testing.T, cfg *config.RateLimit)
+		ExpectErr   bool
+	}{
+		{
+			Description: "defaults",
+			Check: func(t *testing.T, cfg *config.RateLimit) {
+				assert.Equal(t, config.RateLimitStoreRedis, cfg.Store)
+				assert.Equal(t, int64(1), cfg.Policies["post"].Rate)
+				assert.Equal(t, config.RateLimitKeyAPIKey, cfg.Policies["api"].Key)
+				assert.Equal(t, config.RateLimitFailFallback, cfg.Failure)
+				assert.Equal(t, 100*time.Millisecond, cfg.Timeout)
+				assert.Equal(t, 5, cfg.BreakerThreshold)
+				assert.Equal(t, 30*time.Second, cfg.BreakerCooldown)
+			},
+		},
+		{
+			Description: "failure policy and breaker",
+			Env: map[string]string{
+				"RATE_LIMIT_FAILURE":           "closed",
+				"RATE_LIMIT_TIMEOUT":           "50ms",
+				"RATE_LIMIT_BREAKER_THRESHOLD": "3",
+				"RATE_LIMIT_BREAKER_COOLDOWN":  "1m",
+			},
+			Check: func(t *testing.T, cfg *config.RateLimit) {
+				assert.Equal(t, config.RateLimitFailClosed, cfg.Failure)
+				assert.Equal(t, 50*time.Millisecond, cfg.Timeout)
+				assert.Equal(t, 3, cfg.BreakerThreshold)
+				assert.Equal(t, time.Minute, cfg.BreakerCooldown)
+			},
+		},
+		{
+			Description: "invalid failure policy",
+			Env:         map[string]string{"RATE_LIMIT_FAILURE": "maybe"},
+			ExpectErr:   true,
+		},
+		{
+			Description: "invalid breaker threshold",
+			Env:         map[string]string{"RATE_LIMIT_BREAKER_THRESHOLD": "0"},
+			ExpectErr:   true,
+		},
+		{
+			Description: "env overrides a policy by name",
+			Env: map[string]string{
+				"RATE_LIMIT_POLICIES": `{"post":{"algorithm":"sliding_log","rate":5,"period":"10m","key":"ip"}}`,
+			},
+			Check: func(t *testing.T, cfg *config.RateLimit) {
+				post := cfg.Policies["post"]
+				assert.Equal(t, "sliding_log", post.Algorithm)
+				assert.Equal(t, int64(5), post.Rate)
+				assert.Equal(t, 10*time.Minute, post.Period.Duration)
+				assert.Equal(t, int64(120), cfg.Policies["home"].Rate)
+			},
+		},
+		{
+			Description: "policy can be disabled",
+			Env: map[string]string{
+				"RATE_LIMIT_POLICIES": `{"home":{"disabled":true}}`,
+			},
+			Check: func(t *testing.T, cfg *config.RateLimit) {
+				assert.True(t, cfg.Policies["home"].Disabled)
+			},
+		},
+		{
+			Description: "invalid key",
+			Env: map[string]string{
+				"RATE_LIMIT_POLICIES": `{"home":{"algorithm":"gcra","rate":1,"period":"1m","key":"cookie"}}`,
+			},
+			ExpectErr: true,
+		},
+		{
+			Description: "api key",
+			Env: map[string]string{
+				"RATE_LIMIT_POLICIES": `{"home":{"algorithm":"gcra","rate":1,"period":"1m","key":"api_key"}}`,
+			},
+			Check: func(t *testing.T, cfg *config.RateLimit) {
+				assert.Equal(t, config.RateLimitKeyAPIKey, cfg.Policies["home"].Key)
+			},
+		},
+		{
+			Description: "invalid period",
+			Env: map[string]string{
+				"RATE_LIMIT_POLICIES": `{"home":{"algorithm":"gcra","rate":1,"period":"soon","key":"ip"}}`,
+			},
+			ExpectErr: true,
+		},
+		{
+			Description: "invalid store",
+			Env:         map[string]string{"RATE_LIMIT_STORE": "disk"},
+			ExpectErr:   true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Description, func(t *testing.T) {
+			for k, v := range tc.Env {
+				t.Setenv(k, v)
+			}
+
+			cfg, err := config.NewRateLimit()
+			if tc.ExpectErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			tc.Check(t, cfg)
+		})
+	}
+}
+
+func TestRateLimitPoliciesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policies.json")
+	err := os.WriteFile(path, []byte(`{"api":{"algorithm":"fixed_window","rate":10,"period":"1s","key":"session"}}`), 0o600)
+	require.NoError(t, err)
+
+	t.Setenv("RATE_LIMIT_POLICIES_FILE", path)
+
+	cfg, err := config.NewRateLimit()
+	require.NoError(t, err)
+
+	assert.Equal(t, "fixed_window", cfg.Policies["api"].Algorithm)
+	assert.Equal(t, config.RateLimitKeySession, cfg.Policies["api"].Key)
+}
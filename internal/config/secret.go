@@ -0,0 +1,72 @@
+package config
+
+import (
+	"crypto/rand"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const minSecretLength = 32
+
+// EnvDevelopment is the APP_ENV of a local development instance, the only
+// kind allowed to run without a configured secret.
+const EnvDevelopment = "development"
+
+// Secret holds the key used to sign and hash values the guestbook hands out
+// or stores, such as visitor identities.
+type Secret struct {
+	Key []byte
+	// Generated is true when no SECRET_KEY was set in development and a
+	// random key was created instead. Such a key is lost on restart and
+	// isn't shared with other replicas.
+	Generated bool
+}
+
+func lookupSecretKey() (string, bool, error) {
+	if value, ok := os.LookupEnv("SECRET_KEY"); ok && value != "" {
+		return value, true, nil
+	}
+
+	path, ok := os.LookupEnv("SECRET_KEY_FILE")
+	if !ok || path == "" {
+		return "", false, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to read from secret key file: %w", err)
+	}
+
+	return strings.TrimSpace(string(data)), true, nil
+}
+
+// NewSecret creates a secret from the SECRET_KEY environment variable, or
+// the file named by SECRET_KEY_FILE. Every replica has to sign with the same
+// key, so it is an error for neither to be set unless APP_ENV is
+// development, where a random key is generated instead.
+func NewSecret() (*Secret, error) {
+	value, ok, err := lookupSecretKey()
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		if os.Getenv("APP_ENV") != EnvDevelopment {
+			return nil, fmt.Errorf("no SECRET_KEY or SECRET_KEY_FILE env var set")
+		}
+
+		key := make([]byte, minSecretLength)
+		if _, err := rand.Read(key); err != nil {
+			return nil, fmt.Errorf("failed to generate secret: %w", err)
+		}
+
+		return &Secret{Key: key, Generated: true}, nil
+	}
+
+	if len(value) < minSecretLength {
+		return nil, fmt.Errorf("SECRET_KEY must be at least %d characters", minSecretLength)
+	}
+
+	return &Secret{Key: []byte(value)}, nil
+}
@@ -0,0 +1,85 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dreamsofcode-io/guestbook/internal/config"
+)
+
+func TestNewSecret(t *testing.T) {
+	key := "0123456789abcdef0123456789abcdef"
+
+	path := filepath.Join(t.TempDir(), "secret-key")
+	require.NoError(t, os.WriteFile(path, []byte(key+"\n"), 0o600))
+
+	testCases := []struct {
+		Description string
+		Env         map[string]string
+		Expected    string
+		Generated   bool
+		ExpectErr   bool
+	}{
+		{
+			Description: "missing outside development",
+			ExpectErr:   true,
+		},
+		{
+			Description: "generated in development",
+			Env:         map[string]string{"APP_ENV": "development"},
+			Generated:   true,
+		},
+		{
+			Description: "from env",
+			Env:         map[string]string{"SECRET_KEY": key},
+			Expected:    key,
+		},
+		{
+			Description: "from file",
+			Env:         map[string]string{"SECRET_KEY_FILE": path},
+			Expected:    key,
+		},
+		{
+			Description: "missing file",
+			Env:         map[string]string{"SECRET_KEY_FILE": path + ".missing"},
+			ExpectErr:   true,
+		},
+		{
+			Description: "too short",
+			Env:         map[string]string{"SECRET_KEY": "short"},
+			ExpectErr:   true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Description, func(t *testing.T) {
+			for _, k := range []string{"APP_ENV", "SECRET_KEY", "SECRET_KEY_FILE"} {
+				t.Setenv(k, "")
+				os.Unsetenv(k)
+			}
+
+			for k, v := range tc.Env {
+				t.Setenv(k, v)
+			}
+
+			secret, err := config.NewSecret()
+			if tc.ExpectErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.Generated, secret.Generated)
+
+			if tc.Generated {
+				assert.Len(t, secret.Key, 32)
+			} else {
+				assert.Equal(t, tc.Expected, string(secret.Key))
+			}
+		})
+	}
+}
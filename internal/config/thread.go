@@ -0,0 +1,44 @@
+package config
+
+import "fmt"
+
+const (
+	defaultMaxDepth = 3
+	maxMaxDepth     = 10
+)
+
+// Threads holds the configuration for replies to entries.
+type Threads struct {
+	// MaxDepth is how deeply replies may be nested. Top level entries
+	// have a depth of zero, so a depth of one only allows direct replies.
+	MaxDepth int
+}
+
+// NewThreads creates a threads configuration from the THREAD_MAX_DEPTH
+// environment variable.
+func NewThreads() (*Threads, error) {
+	maxDepth, err := lookupInt("THREAD_MAX_DEPTH", defaultMaxDepth)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &Threads{
+		MaxDepth: maxDepth,
+	}
+
+	err = config.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("failed to validate config: %w", err)
+	}
+
+	return config, nil
+}
+
+// Validate checks the maximum depth is within range.
+func (c *Threads) Validate() error {
+	if c.MaxDepth < 0 || c.MaxDepth > maxMaxDepth {
+		return fmt.Errorf("thread depth must be between 0 and %d", maxMaxDepth)
+	}
+
+	return nil
+}
@@ -0,0 +1,48 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+const defaultMessagesPerMinute = 20
+
+// WebSocket holds the configuration for the websocket endpoint.
+type WebSocket struct {
+	// AllowedOrigins are host patterns, such as "*.example.com", that may
+	// connect from another origin. Same origin connections are always
+	// allowed.
+	AllowedOrigins []string
+	// MessagesPerMinute is how many messages a single connection may send.
+	MessagesPerMinute int
+}
+
+// NewWebSocket creates a websocket configuration from the WS_ALLOWED_ORIGINS
+// environment variable, a comma separated list of host patterns, and
+// WS_MESSAGES_PER_MINUTE.
+func NewWebSocket() (*WebSocket, error) {
+	messages, err := lookupInt("WS_MESSAGES_PER_MINUTE", defaultMessagesPerMinute)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &WebSocket{
+		MessagesPerMinute: messages,
+	}
+
+	for _, value := range strings.Split(os.Getenv("WS_ALLOWED_ORIGINS"), ",") {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+
+		config.AllowedOrigins = append(config.AllowedOrigins, value)
+	}
+
+	if config.MessagesPerMinute <= 0 {
+		return nil, fmt.Errorf("invalid WS_MESSAGES_PER_MINUTE")
+	}
+
+	return config, nil
+}
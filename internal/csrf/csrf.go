@@ -0,0 +1,183 @@
+// Package csrf stops other sites from submitting forms on a visitor's
+// behalf. Each visitor gets a random value in a cookie, and forms carry a
+// token signed from it that other sites can neither read nor forge.
+package csrf
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"net/http"
+
+	"github.com/dreamsofcode-io/guestbook/internal/clientip"
+	"github.com/dreamsofcode-io/guestbook/internal/middleware"
+)
+
+const (
+	// CookieName is the cookie holding the visitor's random value.
+	CookieName = "guestbook_csrf"
+	// FieldName is the form field carrying the token.
+	FieldName = "csrf_token"
+)
+
+// nonceSize is the number of random bytes in the cookie.
+const nonceSize = 32
+
+type contextKey struct{}
+
+// Funcs are the template functions for rendering the token into forms.
+var Funcs = template.FuncMap{
+	"csrfField": Field,
+}
+
+// Field returns the hidden input carrying a token, for templates to place
+// inside each form that changes state.
+func Field(token string) template.HTML {
+	return template.HTML(
+		`<input type="hidden" name="` + FieldName + `" value="` +
+			template.HTMLEscapeString(token) + `">`,
+	)
+}
+
+// Token returns the token for the forms of a page, stored by the
+// middleware.
+func Token(r *http.Request) string {
+	token, _ := r.Context().Value(contextKey{}).(string)
+	return token
+}
+
+type errorPage struct {
+	StatusCode    int
+	StatusMessage string
+	ErrorMessage  string
+}
+
+// Protector checks the token on form submissions. Tokens are signed, so
+// a cookie planted by a neighbouring subdomain doesn't help an attacker
+// who can't also compute the token.
+type Protector struct {
+	key  []byte
+	tmpl *template.Template
+	// apiKeys checks the API keys that let a request skip the origin
+	// check. It may be nil, in which case no key does.
+	apiKeys middleware.Validator
+}
+
+func New(key []byte, tmpl *template.Template, apiKeys middleware.Validator) *Protector {
+	return &Protector{
+		key:     key,
+		tmpl:    tmpl,
+		apiKeys: apiKeys,
+	}
+}
+
+func (p *Protector) sign(nonce string) string {
+	mac := hmac.New(sha256.New, p.key)
+	mac.Write([]byte("csrf:"))
+	mac.Write([]byte(nonce))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func validNonce(nonce string) bool {
+	buf, err := base64.RawURLEncoding.DecodeString(nonce)
+	return err == nil && len(buf) == nonceSize
+}
+
+// nonce returns the visitor's random value, handing out a new one when the
+// request doesn't carry one. Fresh reports whether it was just created.
+func (p *Protector) nonce(
+	w http.ResponseWriter, r *http.Request,
+) (nonce string, fresh bool, err error) {
+	if c, err := r.Cookie(CookieName); err == nil && validNonce(c.Value) {
+		return c.Value, false, nil
+	}
+
+	buf := make([]byte, nonceSize)
+	if _, err := rand.Read(buf); err != nil {
+		return "", false, fmt.Errorf("generate nonce: %w", err)
+	}
+
+	nonce = base64.RawURLEncoding.EncodeToString(buf)
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     CookieName,
+		Value:    nonce,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   clientip.Secure(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	return nonce, true, nil
+}
+
+func (p *Protector) reject(w http.ResponseWriter, message string) {
+	w.Header().Set("Content-Type", "text/html")
+	w.WriteHeader(http.StatusForbidden)
+	p.tmpl.ExecuteTemplate(w, "error.html", errorPage{
+		StatusCode:    http.StatusForbidden,
+		StatusMessage: http.StatusText(http.StatusForbidden),
+		ErrorMessage:  message,
+	})
+}
+
+// Middleware makes the token available to pages through Token, and refuses
+// state changing requests that come from another site or don't carry the
+// token for the visitor's cookie.
+func (p *Protector) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		nonce, fresh, err := p.nonce(w, r)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		token := p.sign(nonce)
+
+		if !middleware.SafeMethod(r.Method) {
+			if middleware.CrossOrigin(r) {
+				p.reject(w, "This form was sent from another site.")
+				return
+			}
+
+			if fresh || !hmac.Equal([]byte(r.PostFormValue(FieldName)), []byte(token)) {
+				p.reject(w, "This form has expired. Go back, reload the page and try again.")
+				return
+			}
+		}
+
+		ctx := context.WithValue(r.Context(), contextKey{}, token)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// validAPIKey reports whether the request carries an API key an admin
+// handed out.
+func (p *Protector) validAPIKey(r *http.Request) bool {
+	key := middleware.APIKey(r)
+	return key != "" && p.apiKeys != nil && p.apiKeys(r.Context(), key)
+}
+
+// API refuses state changing API requests that a browser says came from
+// another site, unless they are authenticated with a valid API key. A key
+// isn't sent by the browser on its own, so a forged request can't carry
+// one.
+func (p *Protector) API(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !middleware.SafeMethod(r.Method) && middleware.CrossOrigin(r) && !p.validAPIKey(r) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(
+				`{"error":{"code":"cross_origin","message":"Requests from other sites aren't allowed"}}`,
+			))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
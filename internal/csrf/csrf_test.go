@@ -0,0 +1,246 @@
+package csrf_test
+
+import (
+	"context"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dreamsofcode-io/guestbook/internal/csrf"
+)
+
+var errorTmpl = template.Must(
+	template.New("").Parse(`{{ define "error.html" }}{{ .ErrorMessage }}{{ end }}`),
+)
+
+// visit loads a page through the middleware, returning the cookie and
+// token it hands out.
+func visit(t *testing.T, p *csrf.Protector) (*http.Cookie, string) {
+	t.Helper()
+
+	var token string
+	handler := p.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token = csrf.Token(r)
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "http://example.com/", nil))
+
+	cookies := w.Result().Cookies()
+	require.Len(t, cookies, 1)
+	require.Equal(t, csrf.CookieName, cookies[0].Name)
+	require.NotEmpty(t, token)
+
+	return cookies[0], token
+}
+
+func TestMiddleware(t *testing.T) {
+	p := csrf.New([]byte("0123456789abcdef0123456789abcdef"), errorTmpl, nil)
+	cookie, token := visit(t, p)
+	otherCookie, _ := visit(t, p)
+	_, otherToken := visit(t, p)
+
+	testCases := []struct {
+		Description string
+		Cookie      *http.Cookie
+		Token       string
+		Headers     map[string]string
+		Expected    int
+		Message     string
+	}{
+		{
+			Description: "valid token",
+			Cookie:      cookie,
+			Token:       token,
+			Expected:    http.StatusOK,
+		},
+		{
+			Description: "same origin",
+			Cookie:      cookie,
+			Token:       token,
+			Headers:     map[string]string{"Sec-Fetch-Site": "same-origin"},
+			Expected:    http.StatusOK,
+		},
+		{
+			Description: "missing token",
+			Cookie:      cookie,
+			Expected:    http.StatusForbidden,
+			Message:     "expired",
+		},
+		{
+			Description: "missing cookie",
+			Token:       token,
+			Expected:    http.StatusForbidden,
+			Message:     "expired",
+		},
+		{
+			Description: "another visitor's cookie",
+			Cookie:      otherCookie,
+			Token:       token,
+			Expected:    http.StatusForbidden,
+			Message:     "expired",
+		},
+		{
+			Description: "another visitor's token",
+			Cookie:      cookie,
+			Token:       otherToken,
+			Expected:    http.StatusForbidden,
+			Message:     "expired",
+		},
+		{
+			Description: "forged cookie",
+			Cookie:      &http.Cookie{Name: csrf.CookieName, Value: "forged"},
+			Token:       token,
+			Expected:    http.StatusForbidden,
+			Message:     "expired",
+		},
+		{
+			Description: "cross site",
+			Cookie:      cookie,
+			Token:       token,
+			Headers:     map[string]string{"Sec-Fetch-Site": "cross-site"},
+			Expected:    http.StatusForbidden,
+			Message:     "another site",
+		},
+		{
+			Description: "other origin",
+			Cookie:      cookie,
+			Token:       token,
+			Headers:     map[string]string{"Origin": "https://evil.example"},
+			Expected:    http.StatusForbidden,
+			Message:     "another site",
+		},
+	}
+
+	handler := p.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for _, tc := range testCases {
+		t.Run(tc.Description, func(t *testing.T) {
+			form := url.Values{"message": {"hello"}}
+			if tc.Token != "" {
+				form.Set(csrf.FieldName, tc.Token)
+			}
+
+			req := httptest.NewRequest(
+				http.MethodPost, "http://example.com/", strings.NewReader(form.Encode()),
+			)
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if tc.Cookie != nil {
+				req.AddCookie(tc.Cookie)
+			}
+
+			for k, v := range tc.Headers {
+				req.Header.Set(k, v)
+			}
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.Expected, w.Code)
+			assert.Contains(t, w.Body.String(), tc.Message)
+		})
+	}
+}
+
+func TestAPI(t *testing.T) {
+	p := csrf.New(
+		[]byte("0123456789abcdef0123456789abcdef"), errorTmpl,
+		func(ctx context.Context, key string) bool { return key == "valid" },
+	)
+
+	testCases := []struct {
+		Description string
+		Headers     map[string]string
+		Expected    int
+	}{
+		{
+			Description: "no browser headers",
+			Expected:    http.StatusOK,
+		},
+		{
+			Description: "same origin",
+			Headers:     map[string]string{"Sec-Fetch-Site": "same-origin"},
+			Expected:    http.StatusOK,
+		},
+		{
+			Description: "cross site",
+			Headers:     map[string]string{"Sec-Fetch-Site": "cross-site"},
+			Expected:    http.StatusForbidden,
+		},
+		{
+			Description: "matching origin",
+			Headers:     map[string]string{"Origin": "http://example.com"},
+			Expected:    http.StatusOK,
+		},
+		{
+			Description: "opaque origin",
+			Headers:     map[string]string{"Origin": "null"},
+			Expected:    http.StatusForbidden,
+		},
+		{
+			Description: "cross site with made up api key",
+			Headers: map[string]string{
+				"Sec-Fetch-Site": "cross-site",
+				"X-API-Key":      "x",
+			},
+			Expected: http.StatusForbidden,
+		},
+		{
+			Description: "cross origin with made up bearer token",
+			Headers: map[string]string{
+				"Origin":        "https://other.example",
+				"Authorization": "Bearer x",
+			},
+			Expected: http.StatusForbidden,
+		},
+		{
+			Description: "cross site with valid api key",
+			Headers: map[string]string{
+				"Sec-Fetch-Site": "cross-site",
+				"X-API-Key":      "valid",
+			},
+			Expected: http.StatusOK,
+		},
+		{
+			Description: "cross origin with valid bearer token",
+			Headers: map[string]string{
+				"Origin":        "https://other.example",
+				"Authorization": "Bearer valid",
+			},
+			Expected: http.StatusOK,
+		},
+	}
+
+	handler := p.API(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for _, tc := range testCases {
+		t.Run(tc.Description, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "http://example.com/api/v1/guests", nil)
+			for k, v := range tc.Headers {
+				req.Header.Set(k, v)
+			}
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.Expected, w.Code)
+		})
+	}
+}
+
+func TestField(t *testing.T) {
+	assert.Equal(
+		t, template.HTML(`<input type="hidden" name="csrf_token" value="a&lt;b">`),
+		csrf.Field("a<b"),
+	)
+}
@@ -0,0 +1,91 @@
+// Package edittoken issues the secret tokens that let visitors edit or delete
+// their own entries without an account.
+package edittoken
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// cookiePrefix is followed by the entry's id, so an author can hold tokens
+// for several entries at once.
+const cookiePrefix = "guestbook_edit_"
+
+// Signer signs tokens for the cookie they are handed out in, binding each
+// one to its entry so a cookie can't be moved to another.
+type Signer struct {
+	key []byte
+}
+
+func New(key []byte) *Signer {
+	return &Signer{
+		key: key,
+	}
+}
+
+// CookieName is the name of the cookie holding the token for an entry.
+func CookieName(id uuid.UUID) string {
+	return cookiePrefix + id.String()
+}
+
+// Generate creates a random token along with the hash that is stored in
+// its place.
+func Generate() (string, []byte, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", nil, fmt.Errorf("generate token: %w", err)
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(buf)
+
+	return token, Hash(token), nil
+}
+
+// Hash returns the hash of a token that is stored in the database. Only the
+// hash is stored, so a database leak can't be used to edit entries.
+func Hash(token string) []byte {
+	sum := sha256.Sum256([]byte(token))
+	return sum[:]
+}
+
+// Matches reports whether a token is the one a hash was made from.
+func Matches(token string, hash []byte) bool {
+	return len(hash) > 0 && subtle.ConstantTimeCompare(Hash(token), hash) == 1
+}
+
+func (s *Signer) mac(id uuid.UUID, token string) []byte {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte("edit:"))
+	mac.Write(id[:])
+	mac.Write([]byte(token))
+
+	return mac.Sum(nil)
+}
+
+// Sign returns the cookie value carrying a token for an entry.
+func (s *Signer) Sign(id uuid.UUID, token string) string {
+	return token + "." + base64.RawURLEncoding.EncodeToString(s.mac(id, token))
+}
+
+// Verify checks a cookie value was signed for the entry and returns the
+// token it carries.
+func (s *Signer) Verify(id uuid.UUID, value string) (string, bool) {
+	token, sig, ok := strings.Cut(value, ".")
+	if !ok || token == "" {
+		return "", false
+	}
+
+	got, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(got, s.mac(id, token)) {
+		return "", false
+	}
+
+	return token, true
+}
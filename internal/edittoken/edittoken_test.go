@@ -0,0 +1,56 @@
+package edittoken_test
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dreamsofcode-io/guestbook/internal/edittoken"
+)
+
+func TestGenerate(t *testing.T) {
+	token, hash, err := edittoken.Generate()
+	require.NoError(t, err)
+
+	assert.True(t, edittoken.Matches(token, hash))
+	assert.False(t, edittoken.Matches(token+"x", hash))
+	assert.False(t, edittoken.Matches(token, nil))
+
+	other, _, err := edittoken.Generate()
+	require.NoError(t, err)
+	assert.NotEqual(t, token, other)
+}
+
+func TestSigner(t *testing.T) {
+	signer := edittoken.New([]byte("secret"))
+	id := uuid.New()
+
+	value := signer.Sign(id, "token")
+
+	token, ok := signer.Verify(id, value)
+	assert.True(t, ok)
+	assert.Equal(t, "token", token)
+
+	testCases := []struct {
+		Description string
+		Signer      *edittoken.Signer
+		ID          uuid.UUID
+		Value       string
+	}{
+		{Description: "other entry", Signer: signer, ID: uuid.New(), Value: value},
+		{Description: "other key", Signer: edittoken.New([]byte("other")), ID: id, Value: value},
+		{Description: "changed token", Signer: signer, ID: id, Value: "x" + value},
+		{Description: "unsigned", Signer: signer, ID: id, Value: "token"},
+		{Description: "empty token", Signer: signer, ID: id, Value: "." + value[len("token."):]},
+		{Description: "bad signature", Signer: signer, ID: id, Value: "token.!!"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Description, func(t *testing.T) {
+			_, ok := tc.Signer.Verify(tc.ID, tc.Value)
+			assert.False(t, ok)
+		})
+	}
+}
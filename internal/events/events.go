@@ -0,0 +1,190 @@
+// Package events fans new guest entries out to live subscribers, such as
+// browsers connected to the server-sent events stream.
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/dreamsofcode-io/guestbook/internal/repository"
+)
+
+var (
+	ErrTooManyConnections = errors.New("too many connections")
+	ErrClosed             = errors.New("broker closed")
+)
+
+// buffer is how many events a subscriber may fall behind before it is
+// dropped. Dropped subscribers are expected to reconnect and resume.
+const buffer = 32
+
+// TimestampFormat matches the timestamps rendered in index.html.
+const TimestampFormat = "02 Jan 06 15:04 MST"
+
+// Event is a single message sent to subscribers.
+type Event struct {
+	// ID is the UUIDv7 of the entry, so later events have greater IDs.
+	ID   string
+	Name string
+	Data []byte
+	// Book is the slug of the book the entry belongs to, or empty for the
+	// default book, so subscribers can pick out the book they are showing.
+	Book string
+}
+
+// Guest is the payload of a "guest" event. The message is not escaped, so
+// clients must insert it as text.
+type Guest struct {
+	ID        string    `json:"id"`
+	Message   string    `json:"message"`
+	Name      string    `json:"name,omitempty"`
+	Website   string    `json:"website,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	Timestamp string    `json:"timestamp"`
+	// ParentID is set when the entry is a reply.
+	ParentID string `json:"parent_id,omitempty"`
+}
+
+// NewGuestEvent creates the event announcing a published entry.
+func NewGuestEvent(g repository.Guest) Event {
+	guest := Guest{
+		ID:        g.ID.String(),
+		Message:   g.Message,
+		Name:      g.Name,
+		Website:   g.Website,
+		CreatedAt: g.CreatedAt,
+		Timestamp: g.CreatedAt.Format(TimestampFormat),
+	}
+
+	if g.ParentID.Valid {
+		guest.ParentID = uuid.UUID(g.ParentID.Bytes).String()
+	}
+
+	data, _ := json.Marshal(guest)
+
+	return Event{
+		ID:   g.ID.String(),
+		Name: "guest",
+		Data: data,
+	}
+}
+
+// Broker delivers published events to every subscriber.
+type Broker struct {
+	maxPerClient int
+
+	mu      sync.Mutex
+	subs    map[*Subscription]struct{}
+	clients map[string]int
+	closed  bool
+}
+
+// NewBroker creates a broker that allows each client at most maxPerClient
+// subscriptions at a time. Zero means no limit.
+func NewBroker(maxPerClient int) *Broker {
+	return &Broker{
+		maxPerClient: maxPerClient,
+		subs:         map[*Subscription]struct{}{},
+		clients:      map[string]int{},
+	}
+}
+
+// Subscription receives events until it is closed.
+type Subscription struct {
+	broker *Broker
+	client string
+	events chan Event
+	once   sync.Once
+}
+
+// Subscribe adds a subscriber for the client, usually its IP address.
+func (b *Broker) Subscribe(client string) (*Subscription, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil, ErrClosed
+	}
+
+	if b.maxPerClient > 0 && b.clients[client] >= b.maxPerClient {
+		return nil, ErrTooManyConnections
+	}
+
+	sub := &Subscription{
+		broker: b,
+		client: client,
+		events: make(chan Event, buffer),
+	}
+
+	b.subs[sub] = struct{}{}
+	b.clients[client]++
+
+	return sub, nil
+}
+
+// Events returns the channel of events. It is closed when the subscription
+// is closed, the subscriber falls too far behind, or the broker closes.
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Close removes the subscription from the broker.
+func (s *Subscription) Close() {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+
+	s.broker.remove(s)
+}
+
+// remove must be called with the lock held.
+func (b *Broker) remove(s *Subscription) {
+	s.once.Do(func() {
+		delete(b.subs, s)
+
+		b.clients[s.client]--
+		if b.clients[s.client] <= 0 {
+			delete(b.clients, s.client)
+		}
+
+		close(s.events)
+	})
+}
+
+// Publish sends the event to every subscriber without blocking.
+func (b *Broker) Publish(e Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for sub := range b.subs {
+		select {
+		case sub.events <- e:
+		default:
+			b.remove(sub)
+		}
+	}
+}
+
+// Close ends every subscription and refuses new ones, so open streams
+// finish during shutdown.
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+
+	for sub := range b.subs {
+		b.remove(sub)
+	}
+}
+
+// Subscribers returns the number of open subscriptions.
+func (b *Broker) Subscribers() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return len(b.subs)
+}
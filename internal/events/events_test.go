@@ -0,0 +1,93 @@
+package events_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dreamsofcode-io/guestbook/internal/events"
+	"github.com/dreamsofcode-io/guestbook/internal/repository"
+)
+
+func TestBroker(t *testing.T) {
+	broker := events.NewBroker(2)
+
+	a, err := broker.Subscribe("192.0.2.1")
+	require.NoError(t, err)
+
+	b, err := broker.Subscribe("192.0.2.1")
+	require.NoError(t, err)
+
+	_, err = broker.Subscribe("192.0.2.1")
+	assert.ErrorIs(t, err, events.ErrTooManyConnections)
+
+	c, err := broker.Subscribe("192.0.2.2")
+	require.NoError(t, err)
+
+	e := events.Event{ID: "1", Name: "guest", Data: []byte("{}")}
+	broker.Publish(e)
+
+	for _, sub := range []*events.Subscription{a, b, c} {
+		assert.Equal(t, e, <-sub.Events())
+	}
+
+	// Closing frees the slot for another connection
+	b.Close()
+	b.Close()
+
+	_, err = broker.Subscribe("192.0.2.1")
+	assert.NoError(t, err)
+
+	broker.Close()
+	assert.Equal(t, 0, broker.Subscribers())
+
+	_, ok := <-a.Events()
+	assert.False(t, ok)
+
+	_, err = broker.Subscribe("192.0.2.3")
+	assert.ErrorIs(t, err, events.ErrClosed)
+}
+
+func TestBrokerDropsSlowSubscribers(t *testing.T) {
+	broker := events.NewBroker(0)
+
+	sub, err := broker.Subscribe("192.0.2.1")
+	require.NoError(t, err)
+
+	for i := 0; i < 100; i++ {
+		broker.Publish(events.Event{Name: "guest"})
+	}
+
+	assert.Equal(t, 0, broker.Subscribers())
+
+	count := 0
+	for range sub.Events() {
+		count++
+	}
+
+	assert.Less(t, count, 100)
+}
+
+func TestNewGuestEvent(t *testing.T) {
+	id := uuid.Must(uuid.NewV7())
+	createdAt := time.Date(2024, 11, 5, 12, 30, 0, 0, time.UTC)
+
+	e := events.NewGuestEvent(repository.Guest{
+		ID:        id,
+		Message:   "<b>hello</b>",
+		CreatedAt: createdAt,
+	})
+
+	assert.Equal(t, id.String(), e.ID)
+	assert.Equal(t, "guest", e.Name)
+	assert.NotContains(t, string(e.Data), "\n")
+
+	var guest events.Guest
+	require.NoError(t, json.Unmarshal(e.Data, &guest))
+	assert.Equal(t, "<b>hello</b>", guest.Message)
+	assert.Equal(t, "05 Nov 24 12:30 UTC", guest.Timestamp)
+}
@@ -0,0 +1,262 @@
+package events
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/dreamsofcode-io/guestbook/internal/books"
+	"github.com/dreamsofcode-io/guestbook/internal/repository"
+)
+
+// Channel is the postgres channel the guest_published trigger notifies with
+// the ID of every entry that becomes visible.
+const Channel = "guest_published"
+
+const (
+	minBackoff = time.Second
+	maxBackoff = 30 * time.Second
+	// replayMargin is how far before the newest entry seen a replay starts,
+	// to catch entries from other replicas that committed out of order.
+	replayMargin = time.Minute
+	// recentSize is how many published IDs are remembered to drop
+	// duplicates between replays and notifications.
+	recentSize = 512
+	// replayLimit is the page size used when replaying.
+	replayLimit = 100
+)
+
+// Listener re-broadcasts entries published on any replica to the local
+// broker. It holds its own connection to postgres, outside of the pool, and
+// reconnects with backoff when it is lost, replaying anything missed while
+// it was disconnected.
+type Listener struct {
+	logger *slog.Logger
+	config *pgx.ConnConfig
+	repo   *repository.Queries
+	broker *Broker
+
+	lastSeen time.Time
+	recent   *recentIDs
+	// books holds the slug of each book seen by id. Slugs never change.
+	books map[uuid.UUID]string
+}
+
+func NewListener(logger *slog.Logger, db *pgxpool.Pool, broker *Broker) *Listener {
+	return &Listener{
+		logger: logger,
+		config: db.Config().ConnConfig.Copy(),
+		repo:   repository.New(db),
+		broker: broker,
+		recent: newRecentIDs(recentSize),
+		books:  map[uuid.UUID]string{},
+	}
+}
+
+// Run listens for notifications until the context is cancelled.
+func (l *Listener) Run(ctx context.Context) {
+	l.lastSeen = time.Now()
+	backoff := minBackoff
+
+	for {
+		err := l.listen(ctx, func() {
+			backoff = minBackoff
+		})
+		if ctx.Err() != nil {
+			return
+		}
+
+		l.logger.Error("event listener disconnected",
+			slog.Any("error", err),
+			slog.Duration("retry", backoff),
+		)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
+
+func (l *Listener) listen(ctx context.Context, connected func()) error {
+	conn, err := pgx.ConnectConfig(ctx, l.config)
+	if err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+Channel); err != nil {
+		return fmt.Errorf("failed to listen: %w", err)
+	}
+
+	connected()
+
+	// Only replay once listening, so nothing can fall in between
+	if err := l.replay(ctx); err != nil {
+		return fmt.Errorf("failed to replay: %w", err)
+	}
+
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		id, err := uuid.Parse(n.Payload)
+		if err != nil {
+			l.logger.Warn("invalid notification", slog.String("payload", n.Payload))
+			continue
+		}
+
+		if err := l.publishID(ctx, id); err != nil {
+			l.logger.Error("failed to publish notification",
+				slog.String("id", id.String()),
+				slog.Any("error", err),
+			)
+		}
+	}
+}
+
+// replay publishes the entries created since shortly before the newest one
+// seen.
+func (l *Listener) replay(ctx context.Context) error {
+	since := idAt(l.lastSeen.Add(-replayMargin))
+
+	for {
+		guests, err := l.repo.FindApprovedSinceID(ctx, repository.FindApprovedSinceIDParams{
+			ID:       since,
+			PageSize: replayLimit,
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, g := range guests {
+			if err := l.publish(ctx, g); err != nil {
+				return err
+			}
+
+			since = g.ID
+		}
+
+		if len(guests) < replayLimit {
+			return nil
+		}
+	}
+}
+
+func (l *Listener) publishID(ctx context.Context, id uuid.UUID) error {
+	g, err := l.repo.FindByID(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	// It may have been hidden again since the notification was sent
+	if g.Status != repository.GuestStatusApproved {
+		return nil
+	}
+
+	return l.publish(ctx, g)
+}
+
+// bookSlug returns the slug events for entries in a book are tagged with.
+func (l *Listener) bookSlug(ctx context.Context, id uuid.UUID) (string, error) {
+	if slug, ok := l.books[id]; ok {
+		return slug, nil
+	}
+
+	b, err := l.repo.FindGuestbookByID(ctx, id)
+	if err != nil {
+		return "", fmt.Errorf("failed to find guestbook: %w", err)
+	}
+
+	slug := b.Slug
+	if slug == books.Default {
+		slug = ""
+	}
+
+	l.books[id] = slug
+
+	return slug, nil
+}
+
+func (l *Listener) publish(ctx context.Context, g repository.Guest) error {
+	slug, err := l.bookSlug(ctx, g.GuestbookID)
+	if err != nil {
+		return err
+	}
+
+	if !l.recent.add(g.ID) {
+		return nil
+	}
+
+	if g.CreatedAt.After(l.lastSeen) {
+		l.lastSeen = g.CreatedAt
+	}
+
+	e := NewGuestEvent(g)
+	e.Book = slug
+	l.broker.Publish(e)
+
+	return nil
+}
+
+// idAt returns the smallest UUIDv7 that could have been created at t, so
+// entries created after t have greater IDs.
+func idAt(t time.Time) uuid.UUID {
+	var id uuid.UUID
+
+	var ms [8]byte
+	binary.BigEndian.PutUint64(ms[:], uint64(t.UnixMilli()))
+	copy(id[:6], ms[2:])
+
+	id[6] = 0x70
+	id[8] = 0x80
+
+	return id
+}
+
+// recentIDs is a fixed size set that forgets the oldest IDs first.
+type recentIDs struct {
+	ids  []uuid.UUID
+	set  map[uuid.UUID]struct{}
+	next int
+}
+
+func newRecentIDs(size int) *recentIDs {
+	return &recentIDs{
+		ids: make([]uuid.UUID, 0, size),
+		set: make(map[uuid.UUID]struct{}, size),
+	}
+}
+
+// add returns false if the ID was already in the set.
+func (r *recentIDs) add(id uuid.UUID) bool {
+	if _, ok := r.set[id]; ok {
+		return false
+	}
+
+	if len(r.ids) < cap(r.ids) {
+		r.ids = append(r.ids, id)
+	} else {
+		delete(r.set, r.ids[r.next])
+		r.ids[r.next] = id
+		r.next = (r.next + 1) % len(r.ids)
+	}
+
+	r.set[id] = struct{}{}
+
+	return true
+}
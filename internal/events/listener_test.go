@@ -0,0 +1,43 @@
+package events
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIDAt(t *testing.T) {
+	now := time.Now()
+
+	id, err := uuid.NewV7()
+	assert.NoError(t, err)
+
+	before := idAt(now.Add(-time.Second))
+	after := idAt(now.Add(time.Second))
+
+	assert.Equal(t, uuid.Version(7), before.Version())
+	assert.Equal(t, uuid.RFC4122, before.Variant())
+	assert.Equal(t, -1, bytes.Compare(before[:], id[:]))
+	assert.Equal(t, 1, bytes.Compare(after[:], id[:]))
+
+	sec, nsec := before.Time().UnixTime()
+	assert.Equal(t, now.Add(-time.Second).UnixMilli(), time.Unix(sec, nsec).UnixMilli())
+}
+
+func TestRecentIDs(t *testing.T) {
+	recent := newRecentIDs(2)
+
+	a, b, c := uuid.New(), uuid.New(), uuid.New()
+
+	assert.True(t, recent.add(a))
+	assert.False(t, recent.add(a))
+	assert.True(t, recent.add(b))
+	assert.True(t, recent.add(c))
+
+	// a was forgotten to make room for c
+	assert.True(t, recent.add(a))
+	assert.False(t, recent.add(c))
+}
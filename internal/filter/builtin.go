@@ -0,0 +1,132 @@
+package filter
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	goaway "github.com/TwiN/go-away"
+)
+
+// Profanity rejects messages containing profanity, or censors them
+// instead when Censor is set.
+type Profanity struct {
+	Censor bool
+}
+
+func (f *Profanity) Name() string {
+	return "profanity"
+}
+
+func (f *Profanity) Check(message string) Verdict {
+	if !goaway.IsProfane(message) {
+		return Verdict{Action: Allow}
+	}
+
+	if f.Censor {
+		return Verdict{
+			Action:  Rewrite,
+			Message: goaway.Censor(message),
+		}
+	}
+
+	return Verdict{
+		Action: Reject,
+		Reason: "Please don't use profanity",
+	}
+}
+
+// MaxLength rejects messages longer than Max characters.
+type MaxLength struct {
+	Max int
+}
+
+func (f *MaxLength) Name() string {
+	return "max_length"
+}
+
+func (f *MaxLength) Check(message string) Verdict {
+	if utf8.RuneCountInString(message) <= f.Max {
+		return Verdict{Action: Allow}
+	}
+
+	return Verdict{
+		Action: Reject,
+		Reason: fmt.Sprintf("Messages can be at most %d characters", f.Max),
+	}
+}
+
+var linkRe = regexp.MustCompile(`(?i)\b(?:https?://|www\.)\S+`)
+
+// LinkCount holds messages with more than Max links for review, a common
+// trait of spam.
+type LinkCount struct {
+	Max int
+}
+
+func (f *LinkCount) Name() string {
+	return "link_count"
+}
+
+func (f *LinkCount) Check(message string) Verdict {
+	if len(linkRe.FindAllStringIndex(message, -1)) <= f.Max {
+		return Verdict{Action: Allow}
+	}
+
+	return Verdict{
+		Action: Hold,
+		Reason: fmt.Sprintf("Messages with more than %d links are reviewed", f.Max),
+	}
+}
+
+// Denylist rejects messages that match any of its patterns.
+type Denylist struct {
+	Patterns []*regexp.Regexp
+}
+
+// LoadDenylist reads one regular expression per line. Blank lines and
+// lines starting with # are skipped.
+func LoadDenylist(r io.Reader) (*Denylist, error) {
+	list := &Denylist{}
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		re, err := regexp.Compile(text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern on line %d: %w", line, err)
+		}
+
+		list.Patterns = append(list.Patterns, re)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read denylist: %w", err)
+	}
+
+	return list, nil
+}
+
+func (f *Denylist) Name() string {
+	return "denylist"
+}
+
+func (f *Denylist) Check(message string) Verdict {
+	for _, re := range f.Patterns {
+		if re.MatchString(message) {
+			return Verdict{
+				Action: Reject,
+				Reason: "This message isn't allowed",
+			}
+		}
+	}
+
+	return Verdict{Action: Allow}
+}
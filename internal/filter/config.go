@@ -0,0 +1,47 @@
+package filter
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/dreamsofcode-io/guestbook/internal/config"
+)
+
+// FromConfig builds a pipeline containing the configured filters in the
+// configured order.
+func FromConfig(logger *slog.Logger, cfg *config.Filters) (*Pipeline, error) {
+	filters := make([]ContentFilter, 0, len(cfg.Order))
+
+	for _, name := range cfg.Order {
+		switch name {
+		case "profanity":
+			filters = append(filters, &Profanity{Censor: cfg.CensorProfanity})
+		case "max_length":
+			filters = append(filters, &MaxLength{Max: cfg.MaxLength})
+		case "link_count":
+			filters = append(filters, &LinkCount{Max: cfg.MaxLinks})
+		case "denylist":
+			if cfg.DenylistFile == "" {
+				return nil, fmt.Errorf("denylist filter requires FILTER_DENYLIST_FILE")
+			}
+
+			f, err := os.Open(cfg.DenylistFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to open denylist: %w", err)
+			}
+
+			denylist, err := LoadDenylist(f)
+			f.Close()
+			if err != nil {
+				return nil, err
+			}
+
+			filters = append(filters, denylist)
+		default:
+			return nil, fmt.Errorf("unknown content filter: %s", name)
+		}
+	}
+
+	return NewPipeline(logger, filters...), nil
+}
@@ -0,0 +1,129 @@
+// Package filter provides the content filters that every new message is
+// run through before it is stored.
+package filter
+
+import (
+	"log/slog"
+)
+
+// Action is the outcome a filter decides on for a message.
+type Action int
+
+const (
+	// Allow lets the message through to the next filter.
+	Allow Action = iota
+	// Rewrite replaces the message and carries on with the next filter.
+	Rewrite
+	// Hold lets the message through but keeps it back for review.
+	Hold
+	// Reject refuses the message outright.
+	Reject
+)
+
+func (a Action) String() string {
+	switch a {
+	case Allow:
+		return "allow"
+	case Rewrite:
+		return "rewrite"
+	case Hold:
+		return "hold"
+	case Reject:
+		return "reject"
+	default:
+		return "unknown"
+	}
+}
+
+// Verdict is the decision of a single filter.
+type Verdict struct {
+	Action Action
+	// Reason explains a reject or hold and may be shown to the visitor.
+	Reason string
+	// Message is the replacement message for a rewrite.
+	Message string
+}
+
+// ContentFilter checks a message and decides what should happen to it.
+type ContentFilter interface {
+	Name() string
+	Check(message string) Verdict
+}
+
+// Result is the combined outcome of running a message through a pipeline.
+type Result struct {
+	Action Action
+	// Filter is the name of the filter that decided on the action.
+	Filter  string
+	Reason  string
+	Message string
+}
+
+// Pipeline runs messages through an ordered list of filters. The first
+// reject wins, otherwise rewrites are applied in order and any hold
+// means the message is kept back for review.
+type Pipeline struct {
+	logger  *slog.Logger
+	filters []ContentFilter
+}
+
+func NewPipeline(logger *slog.Logger, filters ...ContentFilter) *Pipeline {
+	return &Pipeline{
+		logger:  logger,
+		filters: filters,
+	}
+}
+
+// Run checks the message against every filter in order.
+func (p *Pipeline) Run(message string) Result {
+	res := Result{
+		Action:  Allow,
+		Message: message,
+	}
+
+	for _, f := range p.filters {
+		verdict := f.Check(res.Message)
+
+		switch verdict.Action {
+		case Reject:
+			p.logger.Info(
+				"message rejected by content filter",
+				slog.String("filter", f.Name()),
+				slog.String("reason", verdict.Reason),
+			)
+
+			return Result{
+				Action:  Reject,
+				Filter:  f.Name(),
+				Reason:  verdict.Reason,
+				Message: res.Message,
+			}
+		case Hold:
+			p.logger.Info(
+				"message held by content filter",
+				slog.String("filter", f.Name()),
+				slog.String("reason", verdict.Reason),
+			)
+
+			// The first hold is reported, later ones add nothing new.
+			if res.Action != Hold {
+				res.Action = Hold
+				res.Filter = f.Name()
+				res.Reason = verdict.Reason
+			}
+		case Rewrite:
+			p.logger.Debug(
+				"message rewritten by content filter",
+				slog.String("filter", f.Name()),
+			)
+
+			res.Message = verdict.Message
+			if res.Action == Allow {
+				res.Action = Rewrite
+				res.Filter = f.Name()
+			}
+		}
+	}
+
+	return res
+}
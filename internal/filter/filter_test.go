@@ -0,0 +1,107 @@
+package filter_test
+
+import (
+	"io"
+	"log/slog"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dreamsofcode-io/guestbook/internal/filter"
+)
+
+type staticFilter struct {
+	name    string
+	verdict filter.Verdict
+}
+
+func (f staticFilter) Name() string { return f.name }
+
+func (f staticFilter) Check(string) filter.Verdict { return f.verdict }
+
+func TestPipeline(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	allow := staticFilter{name: "allow", verdict: filter.Verdict{Action: filter.Allow}}
+	hold := staticFilter{name: "hold", verdict: filter.Verdict{Action: filter.Hold, Reason: "held"}}
+	reject := staticFilter{name: "reject", verdict: filter.Verdict{Action: filter.Reject, Reason: "nope"}}
+	rewrite := staticFilter{name: "rewrite", verdict: filter.Verdict{Action: filter.Rewrite, Message: "rewritten"}}
+
+	testCases := []struct {
+		Description string
+		Filters     []filter.ContentFilter
+		Expected    filter.Result
+	}{
+		{
+			Description: "no filters",
+			Expected:    filter.Result{Action: filter.Allow, Message: "hello"},
+		},
+		{
+			Description: "all allow",
+			Filters:     []filter.ContentFilter{allow, allow},
+			Expected:    filter.Result{Action: filter.Allow, Message: "hello"},
+		},
+		{
+			Description: "reject wins over hold",
+			Filters:     []filter.ContentFilter{hold, reject},
+			Expected:    filter.Result{Action: filter.Reject, Filter: "reject", Reason: "nope", Message: "hello"},
+		},
+		{
+			Description: "hold keeps rewrite",
+			Filters:     []filter.ContentFilter{rewrite, hold},
+			Expected:    filter.Result{Action: filter.Hold, Filter: "hold", Reason: "held", Message: "rewritten"},
+		},
+		{
+			Description: "rewrite",
+			Filters:     []filter.ContentFilter{allow, rewrite},
+			Expected:    filter.Result{Action: filter.Rewrite, Filter: "rewrite", Message: "rewritten"},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.Description, func(t *testing.T) {
+			pipeline := filter.NewPipeline(logger, test.Filters...)
+			assert.Equal(t, test.Expected, pipeline.Run("hello"))
+		})
+	}
+}
+
+func TestBuiltinFilters(t *testing.T) {
+	testCases := []struct {
+		Description string
+		Filter      filter.ContentFilter
+		Message     string
+		Expected    filter.Action
+	}{
+		{"profanity allows clean", &filter.Profanity{}, "hello there", filter.Allow},
+		{"profanity rejects", &filter.Profanity{}, "fuck this", filter.Reject},
+		{"profanity censors", &filter.Profanity{Censor: true}, "fuck this", filter.Rewrite},
+		{"max length allows", &filter.MaxLength{Max: 5}, "héllo", filter.Allow},
+		{"max length rejects", &filter.MaxLength{Max: 5}, "hello!", filter.Reject},
+		{"link count allows", &filter.LinkCount{Max: 1}, "see https://example.com", filter.Allow},
+		{"link count holds", &filter.LinkCount{Max: 1}, "https://a.com www.b.com", filter.Hold},
+		{
+			"denylist rejects",
+			&filter.Denylist{Patterns: []*regexp.Regexp{regexp.MustCompile(`(?i)buy now`)}},
+			"BUY NOW cheap",
+			filter.Reject,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.Description, func(t *testing.T) {
+			assert.Equal(t, test.Expected, test.Filter.Check(test.Message).Action)
+		})
+	}
+}
+
+func TestLoadDenylist(t *testing.T) {
+	list, err := filter.LoadDenylist(strings.NewReader("# spam\n\ncasino\n(?i)crypto\n"))
+	assert.NoError(t, err)
+	assert.Len(t, list.Patterns, 2)
+
+	_, err = filter.LoadDenylist(strings.NewReader("(unclosed"))
+	assert.Error(t, err)
+}
@@ -1,18 +1,39 @@
 package guest
 
 import (
+	"errors"
 	"fmt"
 	"net"
+	"net/url"
+	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 )
 
+const (
+	// MaxNameLength is the longest display name allowed, in characters.
+	MaxNameLength = 40
+	// MaxWebsiteLength is the longest website URL allowed.
+	MaxWebsiteLength = 200
+)
+
+var (
+	ErrNameTooLong    = fmt.Errorf("names can be at most %d characters", MaxNameLength)
+	ErrInvalidName    = errors.New("names can't contain control characters")
+	ErrInvalidWebsite = errors.New("websites must be http or https URLs")
+)
+
 type Guest struct {
 	ID        uuid.UUID
 	Message   string
 	CreatedAt time.Time
 	IP        net.IP
+	// Name and Website are optional and empty when not given.
+	Name    string
+	Website string
 }
 
 func NewGuest(message string, ip net.IP) (Guest, error) {
@@ -28,3 +49,45 @@ func NewGuest(message string, ip net.IP) (Guest, error) {
 		IP:        ip,
 	}, nil
 }
+
+// ParseName trims a display name and checks it isn't too long.
+func ParseName(s string) (string, error) {
+	s = strings.Join(strings.Fields(s), " ")
+
+	if utf8.RuneCountInString(s) > MaxNameLength {
+		return "", ErrNameTooLong
+	}
+
+	if strings.IndexFunc(s, unicode.IsControl) >= 0 {
+		return "", ErrInvalidName
+	}
+
+	return s, nil
+}
+
+// ParseWebsite checks a website is an absolute http or https URL and
+// returns it in its normal form. A bare host such as "example.com" is
+// taken to be https.
+func ParseWebsite(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return "", nil
+	}
+
+	if !strings.Contains(s, "://") {
+		s = "https://" + s
+	}
+
+	u, err := url.Parse(s)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") ||
+		u.Host == "" || u.User != nil {
+		return "", ErrInvalidWebsite
+	}
+
+	res := u.String()
+	if len(res) > MaxWebsiteLength {
+		return "", ErrInvalidWebsite
+	}
+
+	return res, nil
+}
@@ -18,14 +18,14 @@ func NewRepo(db *pgxpool.Pool) *Repo {
 }
 
 var insertSQL = `
-INSERT INTO guest (id, message, created_at, updated_at, ip)
-VALUES ($1, $2, $3, $3, $4)
+INSERT INTO guest (id, message, created_at, updated_at, ip, name, website, guestbook_id)
+VALUES ($1, $2, $3, $3, $4, $5, $6, (SELECT id FROM guestbook WHERE slug = 'default'))
 `
 
 func (r *Repo) Insert(ctx context.Context, guest Guest) error {
 	_, err := r.db.Exec(
 		ctx, insertSQL, guest.ID, guest.Message, guest.CreatedAt.UTC(),
-		guest.IP,
+		guest.IP, guest.Name, guest.Website,
 	)
 	if err != nil {
 		return fmt.Errorf("execute sql: %w", err)
@@ -35,7 +35,7 @@ func (r *Repo) Insert(ctx context.Context, guest Guest) error {
 }
 
 var selectSQL = `
-SELECT id, message, created_at, ip
+SELECT id, message, created_at, ip, name, website
 FROM guest
 ORDER BY created_at DESC
 LIMIT $1
@@ -52,7 +52,10 @@ func (r *Repo) FindAll(ctx context.Context, count int) ([]Guest, error) {
 	for rows.Next() {
 		var guest Guest
 
-		err = rows.Scan(&guest.ID, &guest.Message, &guest.CreatedAt, &guest.IP)
+		err = rows.Scan(
+			&guest.ID, &guest.Message, &guest.CreatedAt, &guest.IP,
+			&guest.Name, &guest.Website,
+		)
 		if err != nil {
 			fmt.Println(err)
 			continue
@@ -0,0 +1,338 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/dreamsofcode-io/guestbook/internal/books"
+	"github.com/dreamsofcode-io/guestbook/internal/pagination"
+	"github.com/dreamsofcode-io/guestbook/internal/repository"
+)
+
+// maxRequestBody is the largest request body the API will read.
+const maxRequestBody = 4 << 10
+
+// API serves the versioned JSON interface for guest entries. It shares its
+// storage and validation with the Guestbook it wraps.
+type API struct {
+	guestbook *Guestbook
+	logger    *slog.Logger
+}
+
+func NewAPI(guestbook *Guestbook) *API {
+	return &API{
+		guestbook: guestbook,
+		logger:    guestbook.logger,
+	}
+}
+
+type apiGuest struct {
+	ID        uuid.UUID `json:"id"`
+	Message   string    `json:"message"`
+	Name      string    `json:"name,omitempty"`
+	Website   string    `json:"website,omitempty"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// ParentID is set when the guest is a reply.
+	ParentID *uuid.UUID `json:"parent_id,omitempty"`
+	// Headline and Rank are only set on search results.
+	Headline string  `json:"headline,omitempty"`
+	Rank     float32 `json:"rank,omitempty"`
+	// Reactions is left out of newly created guests, which have none.
+	Reactions map[string]int64 `json:"reactions,omitempty"`
+	Replies   int64            `json:"replies"`
+}
+
+func newAPIGuest(g repository.Guest) apiGuest {
+	res := apiGuest{
+		ID:        g.ID,
+		Message:   g.Message,
+		Name:      g.Name,
+		Website:   g.Website,
+		Status:    string(g.Status),
+		CreatedAt: g.CreatedAt.UTC(),
+		UpdatedAt: g.UpdatedAt.UTC(),
+	}
+
+	if g.ParentID.Valid {
+		parent := uuid.UUID(g.ParentID.Bytes)
+		res.ParentID = &parent
+	}
+
+	return res
+}
+
+type apiGuestList struct {
+	Guests []apiGuest `json:"guests"`
+	Older  string     `json:"older,omitempty"`
+	Newer  string     `json:"newer,omitempty"`
+}
+
+type apiCount struct {
+	Total int64 `json:"total"`
+}
+
+type apiCreateRequest struct {
+	Message  string    `json:"message"`
+	Name     string    `json:"name"`
+	Website  string    `json:"website"`
+	ParentID uuid.UUID `json:"parent_id"`
+	proof
+}
+
+// apiChallenge is the proof of work a client solves before posting a
+// message. It is empty when posting through the API doesn't need one.
+type apiChallenge struct {
+	Challenge  string     `json:"challenge,omitempty"`
+	Difficulty int        `json:"difficulty,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+}
+
+type apiError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type apiErrorBody struct {
+	Error apiError `json:"error"`
+}
+
+func (a *API) writeJSON(w http.ResponseWriter, statusCode int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		a.logger.Error("failed to encode response", slog.Any("error", err))
+	}
+}
+
+func (a *API) writeError(w http.ResponseWriter, statusCode int, code, message string) {
+	a.writeJSON(w, statusCode, apiErrorBody{
+		Error: apiError{
+			Code:    code,
+			Message: message,
+		},
+	})
+}
+
+func (a *API) internalError(w http.ResponseWriter) {
+	a.writeError(
+		w, http.StatusInternalServerError, "internal_error",
+		"Something went wrong",
+	)
+}
+
+// book looks up the book named by the book query parameter, or the default
+// book. When it can't be found, the error has been written and false is
+// returned.
+func (a *API) book(w http.ResponseWriter, r *http.Request) (book, bool) {
+	b, err := a.guestbook.findBook(r.Context(), bookSlug(r))
+	if errors.Is(err, books.ErrNotFound) {
+		a.writeError(w, http.StatusNotFound, "guestbook_not_found", "Guestbook not found")
+		return book{}, false
+	} else if err != nil {
+		a.logger.Error("failed to find guestbook", slog.Any("error", err))
+		a.internalError(w)
+		return book{}, false
+	}
+
+	return b, true
+}
+
+// List returns a page of guests, newest first, or the best matches first
+// when searching with q.
+func (a *API) List(w http.ResponseWriter, r *http.Request) {
+	b, ok := a.book(w, r)
+	if !ok {
+		return
+	}
+
+	page, err := a.guestbook.findPage(r.Context(), b.ID, r.URL.Query())
+	if errors.Is(err, pagination.ErrInvalidCursor) {
+		a.writeError(w, http.StatusBadRequest, "invalid_cursor", "Invalid cursor")
+		return
+	} else if errors.Is(err, errConflictingCursors) || errors.Is(err, errSearchAfter) {
+		a.writeError(w, http.StatusBadRequest, "invalid_cursor", err.Error())
+		return
+	} else if errors.Is(err, errQueryTooLong) {
+		a.writeError(w, http.StatusBadRequest, "invalid_query", err.Error())
+		return
+	} else if err != nil {
+		a.logger.Error("failed to find guests", slog.Any("error", err))
+		a.internalError(w)
+		return
+	}
+
+	if err := a.guestbook.annotate(r.Context(), page.Guests); err != nil {
+		a.logger.Error("failed to count reactions and replies", slog.Any("error", err))
+		a.internalError(w)
+		return
+	}
+
+	res := apiGuestList{
+		Guests: make([]apiGuest, 0, len(page.Guests)),
+		Older:  page.Older,
+		Newer:  page.Newer,
+	}
+
+	for _, e := range page.Guests {
+		g := newAPIGuest(e.Guest)
+		g.Headline = string(e.Headline)
+		g.Rank = e.Rank
+		g.Reactions = newAPIReactions(e.Reactions)
+		g.Replies = e.Replies
+		res.Guests = append(res.Guests, g)
+	}
+
+	a.writeJSON(w, http.StatusOK, res)
+}
+
+// Get returns a single guest by its id.
+func (a *API) Get(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		a.writeError(w, http.StatusBadRequest, "invalid_id", "Invalid guest id")
+		return
+	}
+
+	g, err := a.guestbook.repo.FindByID(r.Context(), id)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && g.Status != repository.GuestStatusApproved) {
+		a.writeError(w, http.StatusNotFound, "not_found", "Guest not found")
+		return
+	} else if err != nil {
+		a.logger.Error("failed to find guest", slog.Any("error", err))
+		a.internalError(w)
+		return
+	}
+
+	guests := []entry{{Guest: g}}
+	if err := a.guestbook.annotate(r.Context(), guests); err != nil {
+		a.logger.Error("failed to count reactions and replies", slog.Any("error", err))
+		a.internalError(w)
+		return
+	}
+
+	res := newAPIGuest(g)
+	res.Reactions = newAPIReactions(guests[0].Reactions)
+	res.Replies = guests[0].Replies
+
+	a.writeJSON(w, http.StatusOK, res)
+}
+
+// Create validates and stores a new guest message. Like the form, it needs
+// a solved challenge from Challenge when POW_API is set.
+func (a *API) Create(w http.ResponseWriter, r *http.Request) {
+	b, ok := a.book(w, r)
+	if !ok {
+		return
+	}
+
+	var req apiCreateRequest
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBody)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		a.writeError(w, http.StatusBadRequest, "invalid_body", "Invalid request body")
+		return
+	}
+
+	_, ip := remoteIP(r)
+
+	sub := submission{
+		Book:    b,
+		Message: req.Message,
+		Name:    req.Name,
+		Website: req.Website,
+		Parent:  req.ParentID,
+		IP:      ip,
+	}
+
+	err := a.guestbook.verifyAPIChallenge(r.Context(), req.proof)
+
+	var g repository.Guest
+	if err == nil {
+		g, err = a.guestbook.submit(r.Context(), sub)
+	}
+
+	var (
+		verr *validationError
+		berr *bannedError
+	)
+	if errors.Is(err, errUnavailable) {
+		a.writeError(w, http.StatusServiceUnavailable, "unavailable", unavailableMessage)
+		return
+	} else if errors.As(err, &berr) {
+		a.writeError(w, http.StatusForbidden, "banned", berr.Error())
+		return
+	} else if errors.As(err, &verr) {
+		a.writeError(w, http.StatusUnprocessableEntity, verr.Code, verr.Message)
+		return
+	} else if err != nil {
+		a.logger.Error("failed to submit guest", slog.Any("error", err))
+		a.internalError(w)
+		return
+	}
+
+	// Pending entries are accepted but can't be fetched until approved.
+	if g.Status == repository.GuestStatusPending {
+		a.writeJSON(w, http.StatusAccepted, newAPIGuest(g))
+		return
+	}
+
+	w.Header().Set("Location", "/api/v1/guests/"+g.ID.String())
+	a.writeJSON(w, http.StatusCreated, newAPIGuest(g))
+}
+
+// Challenge issues the proof of work a client solves for its next message.
+func (a *API) Challenge(w http.ResponseWriter, r *http.Request) {
+	var res apiChallenge
+
+	if a.guestbook.challenges.APIEnabled() {
+		c, err := a.guestbook.challenges.Issue(r.Context(), time.Now())
+		if err != nil {
+			a.logger.Error("failed to issue challenge", slog.Any("error", err))
+			a.internalError(w)
+			return
+		}
+
+		res.Challenge = c.Value
+		res.Difficulty = c.Difficulty
+		expires := c.Expires.UTC()
+		res.ExpiresAt = &expires
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	a.writeJSON(w, http.StatusOK, res)
+}
+
+// Count returns the number of entries, not counting replies, or of the
+// guests matching q.
+func (a *API) Count(w http.ResponseWriter, r *http.Request) {
+	b, ok := a.book(w, r)
+	if !ok {
+		return
+	}
+
+	q, err := searchQuery(r.URL.Query())
+	if err != nil {
+		a.writeError(w, http.StatusBadRequest, "invalid_query", err.Error())
+		return
+	}
+
+	count, err := a.guestbook.count(r.Context(), b.ID, q)
+	if err != nil {
+		a.logger.Error("failed to get count", slog.Any("error", err))
+		a.internalError(w)
+		return
+	}
+
+	a.writeJSON(w, http.StatusOK, apiCount{
+		Total: count,
+	})
+}
@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/dreamsofcode-io/guestbook/internal/books"
+	"github.com/dreamsofcode-io/guestbook/internal/repository"
+)
+
+var errClosed = &validationError{
+	Code:    "closed",
+	Message: "This guestbook isn't accepting new messages",
+}
+
+// book is a guestbook along with the settings that apply to its entries.
+type book struct {
+	repository.Guestbook
+}
+
+// Prefix is prepended to the paths of the book's pages and feeds. It is
+// empty for the default book, which lives at the root.
+func (b book) Prefix() string {
+	if b.Slug == books.Default {
+		return ""
+	}
+
+	return "/b/" + b.Slug
+}
+
+// Path is the address of the book's home page.
+func (b book) Path() string {
+	if b.Slug == books.Default {
+		return "/"
+	}
+
+	return b.Prefix()
+}
+
+// bookSlug returns the slug of the book a request is for, from the path of
+// pages under /b/{slug} or the book query parameter of the API and live
+// streams. An empty slug is the default book.
+func bookSlug(r *http.Request) string {
+	if slug := r.PathValue("slug"); slug != "" {
+		return slug
+	}
+
+	return r.URL.Query().Get("book")
+}
+
+// eventBook returns the book events are tagged with for a slug, which is
+// empty for the default book.
+func eventBook(slug string) string {
+	if slug == books.Default {
+		return ""
+	}
+
+	return slug
+}
+
+// findBook looks up a guestbook by its slug, or the default book when the
+// slug is empty.
+func (h *Guestbook) findBook(ctx context.Context, slug string) (book, error) {
+	if slug == "" {
+		slug = books.Default
+	}
+
+	b, err := h.repo.FindGuestbookBySlug(ctx, slug)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return book{}, books.ErrNotFound
+	} else if err != nil {
+		return book{}, fmt.Errorf("find guestbook: %w", err)
+	}
+
+	return book{b}, nil
+}
+
+// requestBook looks up the book a request is for. When it can't be found,
+// the response has been written and false is returned.
+func (h *Guestbook) requestBook(w http.ResponseWriter, r *http.Request) (book, bool) {
+	b, err := h.findBook(r.Context(), bookSlug(r))
+	if errors.Is(err, books.ErrNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		return book{}, false
+	} else if err != nil {
+		h.logger.Error("failed to find guestbook", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return book{}, false
+	}
+
+	return b, true
+}
+
+// bookByID looks up the guestbook an entry belongs to.
+func (h *Guestbook) bookByID(ctx context.Context, id uuid.UUID) (book, error) {
+	b, err := h.repo.FindGuestbookByID(ctx, id)
+	if err != nil {
+		return book{}, fmt.Errorf("find guestbook: %w", err)
+	}
+
+	return book{b}, nil
+}
@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/dreamsofcode-io/guestbook/internal/pow"
+)
+
+var (
+	errChallengeMissing = &validationError{
+		Code:    "challenge_missing",
+		Message: "A solved proof of work challenge is required",
+	}
+	errChallengeExpired = &validationError{
+		Code:    "challenge_expired",
+		Message: "The challenge has expired, get a new one",
+	}
+	errChallengeUsed = &validationError{
+		Code:    "challenge_used",
+		Message: "The challenge has already been used, get a new one",
+	}
+	errChallengeUnsolved = &validationError{
+		Code:    "challenge_unsolved",
+		Message: "The challenge isn't solved",
+	}
+)
+
+// errUnavailable is returned when a submission can't be checked, so
+// posting has to wait.
+var errUnavailable = errors.New("posting is unavailable")
+
+const unavailableMessage = "Posting is unavailable, try again later"
+
+// proof is the solved challenge a client sends along with a message.
+type proof struct {
+	Challenge string `json:"pow_challenge"`
+	Solution  string `json:"pow_solution"`
+}
+
+// challenge issues the proof of work for the forms on a page, or nil when
+// posting doesn't need one.
+func (h *Guestbook) challenge(r *http.Request) (*pow.Challenge, error) {
+	if !h.challenges.Enabled() {
+		return nil, nil
+	}
+
+	c, err := h.challenges.Issue(r.Context(), time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+// verifyChallenge checks the proof of work sent with a submission. When it
+// doesn't hold up a validationError is returned, or errUnavailable when it
+// can't be checked.
+func (h *Guestbook) verifyChallenge(ctx context.Context, challenge, solution string) error {
+	if !h.challenges.Enabled() {
+		return nil
+	}
+
+	err := h.challenges.Verify(ctx, challenge, solution, time.Now())
+
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, pow.ErrMissing):
+		return errChallengeMissing
+	case errors.Is(err, pow.ErrExpired):
+		return errChallengeExpired
+	case errors.Is(err, pow.ErrReplayed):
+		return errChallengeUsed
+	case errors.Is(err, pow.ErrUnavailable):
+		return errUnavailable
+	default:
+		h.logger.Info("rejected proof of work", slog.Any("error", err))
+		return errChallengeUnsolved
+	}
+}
+
+// verifyAPIChallenge checks the proof of work sent with a submission to
+// the JSON API or websocket, which only need one when POW_API is set.
+func (h *Guestbook) verifyAPIChallenge(ctx context.Context, p proof) error {
+	if !h.challenges.APIEnabled() {
+		return nil
+	}
+
+	return h.verifyChallenge(ctx, p.Challenge, p.Solution)
+}
+
+// checkChallenge verifies the proof of work sent with a form. When it
+// doesn't hold up, the response has been written and false is returned.
+func (h *Guestbook) checkChallenge(w http.ResponseWriter, r *http.Request) bool {
+	err := h.verifyChallenge(
+		r.Context(), r.PostForm.Get(pow.ChallengeField), r.PostForm.Get(pow.SolutionField),
+	)
+
+	switch err {
+	case nil:
+		return true
+	case errChallengeMissing:
+		h.renderError(w, http.StatusBadRequest,
+			"Posting needs JavaScript, which your browser uses to solve a small anti-spam puzzle")
+	case errChallengeExpired:
+		h.renderError(w, http.StatusBadRequest,
+			"The anti-spam puzzle has expired. Go back, reload the page and try again")
+	case errChallengeUsed:
+		h.renderError(w, http.StatusBadRequest,
+			"This form has already been sent. Go back, reload the page and try again")
+	case errUnavailable:
+		h.renderError(w, http.StatusServiceUnavailable, unavailableMessage)
+	default:
+		h.renderError(w, http.StatusBadRequest,
+			"The anti-spam puzzle wasn't solved. Go back, reload the page and try again")
+	}
+
+	return false
+}
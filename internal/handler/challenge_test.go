@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dreamsofcode-io/guestbook/internal/config"
+	"github.com/dreamsofcode-io/guestbook/internal/middleware"
+	"github.com/dreamsofcode-io/guestbook/internal/pow"
+)
+
+func TestVerifyChallenge(t *testing.T) {
+	ctx := context.Background()
+	key := []byte("0123456789abcdef0123456789abcdef")
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	client := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	challenges := pow.New(
+		logger, key, client, middleware.NewBreaker(5, time.Minute),
+		&config.ProofOfWork{Difficulty: 8, MaxDifficulty: 8, SurgeRate: 20, TTL: time.Minute},
+	)
+
+	h := &Guestbook{logger: logger, challenges: challenges}
+
+	c, err := h.challenges.Issue(ctx, time.Now())
+	require.NoError(t, err)
+
+	var solution, wrong string
+	for n := 0; solution == "" || wrong == ""; n++ {
+		if pow.Solves(c.Value, strconv.Itoa(n), c.Difficulty) {
+			solution = strconv.Itoa(n)
+		} else {
+			wrong = strconv.Itoa(n)
+		}
+	}
+
+	testCases := []struct {
+		Description string
+		Proof       proof
+		Err         error
+	}{
+		{
+			Description: "no challenge",
+			Proof:       proof{},
+			Err:         errChallengeMissing,
+		},
+		{
+			Description: "wrong solution",
+			Proof:       proof{Challenge: c.Value, Solution: wrong},
+			Err:         errChallengeUnsolved,
+		},
+		{
+			Description: "solved",
+			Proof:       proof{Challenge: c.Value, Solution: solution},
+		},
+		{
+			Description: "reused",
+			Proof:       proof{Challenge: c.Value, Solution: solution},
+			Err:         errChallengeUsed,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Description, func(t *testing.T) {
+			err := h.verifyChallenge(ctx, tc.Proof.Challenge, tc.Proof.Solution)
+			assert.Equal(t, tc.Err, err)
+		})
+	}
+}
+
+func TestVerifyAPIChallenge(t *testing.T) {
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	client := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	testCases := []struct {
+		Description string
+		API         bool
+		Err         error
+	}{
+		{Description: "api not enabled", API: false},
+		{Description: "api enabled", API: true, Err: errChallengeMissing},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Description, func(t *testing.T) {
+			challenges := pow.New(
+				logger, []byte("0123456789abcdef0123456789abcdef"), client,
+				middleware.NewBreaker(5, time.Minute),
+				&config.ProofOfWork{
+					Difficulty: 8, MaxDifficulty: 8, SurgeRate: 20, TTL: time.Minute, API: tc.API,
+				},
+			)
+			h := &Guestbook{logger: logger, challenges: challenges}
+
+			assert.Equal(t, tc.Err, h.verifyAPIChallenge(ctx, proof{}))
+		})
+	}
+}
@@ -0,0 +1,268 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/dreamsofcode-io/guestbook/internal/clientip"
+	"github.com/dreamsofcode-io/guestbook/internal/csrf"
+	"github.com/dreamsofcode-io/guestbook/internal/edittoken"
+	"github.com/dreamsofcode-io/guestbook/internal/repository"
+)
+
+type editPage struct {
+	Book  book
+	CSRF  string
+	Guest entry
+	// Until is when the entry can no longer be changed.
+	Until time.Time
+	// Deletable is false when the entry has replies, which would be lost
+	// along with it.
+	Deletable bool
+}
+
+// returnTo is where a visitor is sent after changing an entry: the thread
+// it replies to, or the home page of its book. Pending entries aren't shown
+// yet, so the page says they are waiting for a moderator.
+func returnTo(home string, g repository.Guest) string {
+	target := home
+	if g.ParentID.Valid {
+		target = "/guests/" + uuid.UUID(g.ParentID.Bytes).String()
+	}
+
+	if g.Status == repository.GuestStatusPending {
+		target += "?pending"
+	}
+
+	return target
+}
+
+// editUntil returns when the author of an entry can no longer change it.
+func (h *Guestbook) editUntil(g repository.Guest) time.Time {
+	return g.CreatedAt.Add(h.editing.Window)
+}
+
+// setEditCookie hands the author the token for their entry. The cookie
+// expires along with the edit window.
+func (h *Guestbook) setEditCookie(
+	w http.ResponseWriter, r *http.Request, g repository.Guest, token string,
+) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     edittoken.CookieName(g.ID),
+		Value:    h.tokens.Sign(g.ID, token),
+		Path:     "/",
+		Expires:  h.editUntil(g),
+		HttpOnly: true,
+		Secure:   clientip.Secure(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func (h *Guestbook) clearEditCookie(w http.ResponseWriter, r *http.Request, id uuid.UUID) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     edittoken.CookieName(id),
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   clientip.Secure(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// editToken returns the token the request holds for an entry, if the entry
+// can still be changed. Only the cookie's signature is checked, which is
+// enough to decide whether to offer editing; changes also check the token
+// against the stored hash.
+func (h *Guestbook) editToken(r *http.Request, g repository.Guest) (string, bool) {
+	if h.editing.Window <= 0 || g.Status == repository.GuestStatusRejected ||
+		!time.Now().Before(h.editUntil(g)) {
+		return "", false
+	}
+
+	cookie, err := r.Cookie(edittoken.CookieName(g.ID))
+	if err != nil {
+		return "", false
+	}
+
+	return h.tokens.Verify(g.ID, cookie.Value)
+}
+
+// markEditable flags the entries the request is allowed to change.
+func (h *Guestbook) markEditable(r *http.Request, guests []entry) {
+	for i := range guests {
+		_, guests[i].Editable = h.editToken(r, guests[i].Guest)
+	}
+}
+
+// authorize finds the entry being changed and checks the request holds its
+// edit token. When it doesn't, the response has been written and false is
+// returned.
+func (h *Guestbook) authorize(w http.ResponseWriter, r *http.Request) (repository.Guest, bool) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		h.renderError(w, http.StatusNotFound, "That message doesn't exist")
+		return repository.Guest{}, false
+	}
+
+	g, err := h.repo.FindByID(r.Context(), id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		h.renderError(w, http.StatusNotFound, "That message doesn't exist")
+		return repository.Guest{}, false
+	} else if err != nil {
+		h.logger.Error("failed to find guest", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return repository.Guest{}, false
+	}
+
+	token, ok := h.editToken(r, g)
+	if !ok || !edittoken.Matches(token, g.EditTokenHash) {
+		h.renderError(w, http.StatusForbidden, "This message can no longer be changed")
+		return repository.Guest{}, false
+	}
+
+	return g, true
+}
+
+// hasReplies reports whether anyone has replied to an entry.
+func (h *Guestbook) hasReplies(r *http.Request, id uuid.UUID) (bool, error) {
+	replies, err := h.repo.CountReplies(r.Context(), []uuid.UUID{id})
+	if err != nil {
+		return false, err
+	}
+
+	return len(replies) > 0, nil
+}
+
+// EditForm shows the author of an entry a form to change or delete it.
+func (h *Guestbook) EditForm(w http.ResponseWriter, r *http.Request) {
+	g, ok := h.authorize(w, r)
+	if !ok {
+		return
+	}
+
+	b, err := h.bookByID(r.Context(), g.GuestbookID)
+	if err != nil {
+		h.logger.Error("failed to find guestbook", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	replies, err := h.hasReplies(r, g.ID)
+	if err != nil {
+		h.logger.Error("failed to count replies", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add("Content-Type", "text/html")
+	h.tmpl.ExecuteTemplate(w, "edit.html", editPage{
+		Book:      b,
+		CSRF:      csrf.Token(r),
+		Guest:     entry{Guest: g},
+		Until:     h.editUntil(g),
+		Deletable: !replies,
+	})
+}
+
+// Edit changes the message of an entry. The new message goes through the
+// same filters and moderation as a new one, and the old one is kept in the
+// entry's history.
+func (h *Guestbook) Edit(w http.ResponseWriter, r *http.Request) {
+	g, ok := h.authorize(w, r)
+	if !ok {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	b, err := h.bookByID(r.Context(), g.GuestbookID)
+	if err != nil {
+		h.logger.Error("failed to find guestbook", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	message, held, err := h.validateMessage(b, r.PostForm.Get("message"))
+
+	var verr *validationError
+	if errors.As(err, &verr) {
+		h.renderError(w, http.StatusBadRequest, verr.Message)
+		return
+	} else if err != nil {
+		h.logger.Error("failed to validate message", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if message == g.Message {
+		http.Redirect(w, r, returnTo(b.Path(), g), http.StatusFound)
+		return
+	}
+
+	status := g.Status
+	if h.mod.PreModeration || b.PreModeration || held {
+		status = repository.GuestStatusPending
+	}
+
+	g, err = h.repo.EditMessage(r.Context(), repository.EditMessageParams{
+		ID:       g.ID,
+		EditedAt: time.Now(),
+		Message:  message,
+		Status:   status,
+	})
+	if err != nil {
+		h.logger.Error("failed to edit guest", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, returnTo(b.Path(), g), http.StatusFound)
+}
+
+// Delete removes an entry at its author's request. Entries that have been
+// replied to are kept, as deleting them would take the replies with them.
+func (h *Guestbook) Delete(w http.ResponseWriter, r *http.Request) {
+	g, ok := h.authorize(w, r)
+	if !ok {
+		return
+	}
+
+	replies, err := h.hasReplies(r, g.ID)
+	if err != nil {
+		h.logger.Error("failed to count replies", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if replies {
+		h.renderError(w, http.StatusConflict, "Messages that have replies can't be deleted")
+		return
+	}
+
+	b, err := h.bookByID(r.Context(), g.GuestbookID)
+	if err != nil {
+		h.logger.Error("failed to find guestbook", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if _, err := h.repo.DeleteGuest(r.Context(), g.ID); err != nil {
+		h.logger.Error("failed to delete guest", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	h.clearEditCookie(w, r, g.ID)
+
+	// The entry is gone, so only the thread it was in matters.
+	http.Redirect(w, r, returnTo(b.Path(), repository.Guest{ParentID: g.ParentID}), http.StatusFound)
+}
@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dreamsofcode-io/guestbook/internal/repository"
+)
+
+func TestReturnTo(t *testing.T) {
+	parent := uuid.New()
+
+	testCases := []struct {
+		Description string
+		Guest       repository.Guest
+		Expected    string
+	}{
+		{
+			Description: "entry",
+			Guest:       repository.Guest{Status: repository.GuestStatusApproved},
+			Expected:    "/b/friends",
+		},
+		{
+			Description: "pending entry",
+			Guest:       repository.Guest{Status: repository.GuestStatusPending},
+			Expected:    "/b/friends?pending",
+		},
+		{
+			Description: "pending reply",
+			Guest: repository.Guest{
+				Status:   repository.GuestStatusPending,
+				ParentID: pgtype.UUID{Bytes: parent, Valid: true},
+			},
+			Expected: "/guests/" + parent.String() + "?pending",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Description, func(t *testing.T) {
+			assert.Equal(t, tc.Expected, returnTo("/b/friends", tc.Guest))
+		})
+	}
+}
+
+func TestEdited(t *testing.T) {
+	now := time.Now()
+
+	assert.False(t, entry{Guest: repository.Guest{CreatedAt: now, UpdatedAt: now}}.Edited())
+	assert.True(t, entry{Guest: repository.Guest{CreatedAt: now, UpdatedAt: now.Add(time.Second)}}.Edited())
+}
@@ -0,0 +1,300 @@
+package handler
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/xml"
+	"log/slog"
+	"net/http"
+	"time"
+	"unicode/utf8"
+
+	"github.com/dreamsofcode-io/guestbook/internal/clientip"
+	"github.com/dreamsofcode-io/guestbook/internal/config"
+	"github.com/dreamsofcode-io/guestbook/internal/repository"
+)
+
+const (
+	// feedDescription is used for books without a description of their own.
+	feedDescription = "Messages left in the guest book"
+	// feedTitleLength is how much of a message is used as an item's title.
+	feedTitleLength = 80
+)
+
+// Feed serves the newest entries of a book as RSS 2.0 and Atom feeds.
+type Feed struct {
+	guestbook *Guestbook
+	logger    *slog.Logger
+	cfg       *config.Feed
+}
+
+func NewFeed(guestbook *Guestbook, cfg *config.Feed) *Feed {
+	return &Feed{
+		guestbook: guestbook,
+		logger:    guestbook.logger,
+		cfg:       cfg,
+	}
+}
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Atom    string     `xml:"xmlns:atom,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Self          atomLink  `xml:"atom:link"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Description string  `xml:"description"`
+	GUID        rssGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Author  atomAuthor  `xml:"author"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+	URI  string `xml:"uri,omitempty"`
+}
+
+type atomEntry struct {
+	Title     string      `xml:"title"`
+	ID        string      `xml:"id"`
+	Published string      `xml:"published"`
+	Updated   string      `xml:"updated"`
+	Author    *atomAuthor `xml:"author,omitempty"`
+	Content   atomContent `xml:"content"`
+}
+
+type atomContent struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+// baseURL returns the configured public address, or the one the request
+// was made to.
+func (f *Feed) baseURL(r *http.Request) string {
+	if f.cfg.BaseURL != "" {
+		return f.cfg.BaseURL
+	}
+
+	scheme := "http"
+	if clientip.Secure(r) {
+		scheme = "https"
+	}
+
+	return scheme + "://" + r.Host
+}
+
+// itemTitle shortens a message to use as the title of its item.
+func itemTitle(message string) string {
+	if utf8.RuneCountInString(message) <= feedTitleLength {
+		return message
+	}
+
+	runes := []rune(message)
+
+	return string(runes[:feedTitleLength-1]) + "…"
+}
+
+func guid(g repository.Guest) string {
+	return "urn:uuid:" + g.ID.String()
+}
+
+// lastModified returns the most recent change to any of the guests.
+func lastModified(guests []repository.Guest) time.Time {
+	var latest time.Time
+	for _, g := range guests {
+		if g.UpdatedAt.After(latest) {
+			latest = g.UpdatedAt
+		}
+
+		if g.ModeratedAt.Valid && g.ModeratedAt.Time.After(latest) {
+			latest = g.ModeratedAt.Time
+		}
+	}
+
+	return latest.UTC()
+}
+
+// feedETag identifies the exact set of entries in a feed, so hiding or deleting
+// an entry changes it even though no timestamp moves forward.
+func feedETag(format string, guests []repository.Guest) string {
+	h := sha256.New()
+	h.Write([]byte(format))
+
+	for _, g := range guests {
+		h.Write(g.ID[:])
+		h.Write([]byte(g.UpdatedAt.UTC().Format(time.RFC3339Nano)))
+	}
+
+	return `"` + base64.RawURLEncoding.EncodeToString(h.Sum(nil)[:16]) + `"`
+}
+
+// serve writes the feed, answering conditional requests with 304 Not
+// Modified when the client's copy is current.
+func (f *Feed) serve(
+	w http.ResponseWriter, r *http.Request, contentType, format string,
+	guests []repository.Guest, body any,
+) {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+
+	if err := xml.NewEncoder(&buf).Encode(body); err != nil {
+		f.logger.Error("failed to encode feed", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("ETag", feedETag(format, guests))
+	w.Header().Set("Cache-Control", "public, max-age=60")
+
+	http.ServeContent(w, r, "", lastModified(guests), bytes.NewReader(buf.Bytes()))
+}
+
+func (f *Feed) findGuests(w http.ResponseWriter, r *http.Request) (book, []repository.Guest, bool) {
+	b, ok := f.guestbook.requestBook(w, r)
+	if !ok {
+		return book{}, nil, false
+	}
+
+	guests, err := f.guestbook.repo.FindApproved(r.Context(), repository.FindApprovedParams{
+		GuestbookID: b.ID,
+		Limit:       f.cfg.Size,
+	})
+	if err != nil {
+		f.logger.Error("failed to find guests", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return book{}, nil, false
+	}
+
+	return b, guests, true
+}
+
+func feedDescriptionOf(b book) string {
+	if b.Description == "" {
+		return feedDescription
+	}
+
+	return b.Description
+}
+
+// RSS serves the newest entries as an RSS 2.0 feed.
+func (f *Feed) RSS(w http.ResponseWriter, r *http.Request) {
+	b, guests, ok := f.findGuests(w, r)
+	if !ok {
+		return
+	}
+
+	base := f.baseURL(r)
+
+	channel := rssChannel{
+		Title:       b.Title,
+		Link:        base + b.Path(),
+		Description: feedDescriptionOf(b),
+		Self: atomLink{
+			Href: base + b.Prefix() + "/feed.rss",
+			Rel:  "self",
+			Type: "application/rss+xml",
+		},
+		Items: make([]rssItem, 0, len(guests)),
+	}
+
+	if len(guests) > 0 {
+		channel.LastBuildDate = lastModified(guests).Format(time.RFC1123Z)
+	}
+
+	for _, g := range guests {
+		channel.Items = append(channel.Items, rssItem{
+			Title:       itemTitle(g.Message),
+			Description: g.Message,
+			GUID:        rssGUID{Value: guid(g)},
+			PubDate:     g.CreatedAt.UTC().Format(time.RFC1123Z),
+		})
+	}
+
+	f.serve(w, r, "application/rss+xml; charset=utf-8", "rss", guests, rssFeed{
+		Version: "2.0",
+		Atom:    "http://www.w3.org/2005/Atom",
+		Channel: channel,
+	})
+}
+
+// Atom serves the newest entries as an Atom feed.
+func (f *Feed) Atom(w http.ResponseWriter, r *http.Request) {
+	b, guests, ok := f.findGuests(w, r)
+	if !ok {
+		return
+	}
+
+	base := f.baseURL(r)
+
+	updated := lastModified(guests)
+	if updated.IsZero() {
+		updated = time.Now().UTC()
+	}
+
+	feed := atomFeed{
+		Title:   b.Title,
+		ID:      base + b.Path(),
+		Updated: updated.Format(time.RFC3339),
+		Links: []atomLink{
+			{Href: base + b.Path(), Rel: "alternate", Type: "text/html"},
+			{Href: base + b.Prefix() + "/feed.atom", Rel: "self", Type: "application/atom+xml"},
+		},
+		Author:  atomAuthor{Name: b.Title},
+		Entries: make([]atomEntry, 0, len(guests)),
+	}
+
+	for _, g := range guests {
+		entry := atomEntry{
+			Title:     itemTitle(g.Message),
+			ID:        guid(g),
+			Published: g.CreatedAt.UTC().Format(time.RFC3339),
+			Updated:   g.UpdatedAt.UTC().Format(time.RFC3339),
+			Content: atomContent{
+				Type:  "text",
+				Value: g.Message,
+			},
+		}
+
+		// Entries without a name fall back to the feed's author.
+		if g.Name != "" {
+			entry.Author = &atomAuthor{Name: g.Name, URI: g.Website}
+		}
+
+		feed.Entries = append(feed.Entries, entry)
+	}
+
+	f.serve(w, r, "application/atom+xml; charset=utf-8", "atom", guests, feed)
+}
@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dreamsofcode-io/guestbook/internal/config"
+	"github.com/dreamsofcode-io/guestbook/internal/repository"
+)
+
+func TestItemTitle(t *testing.T) {
+	assert.Equal(t, "hello", itemTitle("hello"))
+
+	title := itemTitle(strings.Repeat("é", 100))
+	assert.Equal(t, feedTitleLength, len([]rune(title)))
+	assert.True(t, strings.HasSuffix(title, "…"))
+}
+
+func TestFeedConditionalGet(t *testing.T) {
+	feed := &Feed{
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+		cfg:    &config.Feed{BaseURL: "https://guestbook.example", Size: 10},
+	}
+
+	updated := time.Date(2024, 11, 5, 12, 30, 0, 0, time.UTC)
+	guests := []repository.Guest{{
+		ID:        uuid.Must(uuid.NewV7()),
+		Message:   "<hello> & welcome",
+		CreatedAt: updated,
+		UpdatedAt: updated,
+	}}
+
+	serve := func(header, value string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/feed.atom", nil)
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+
+		w := httptest.NewRecorder()
+		feed.serve(w, req, "application/atom+xml", "atom", guests, atomFeed{
+			Entries: []atomEntry{{Content: atomContent{Value: guests[0].Message}}},
+		})
+
+		return w
+	}
+
+	w := serve("", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "&lt;hello&gt; &amp; welcome")
+	assert.Equal(t, "Tue, 05 Nov 2024 12:30:00 GMT", w.Header().Get("Last-Modified"))
+
+	tag := w.Header().Get("ETag")
+	assert.NotEmpty(t, tag)
+
+	assert.Equal(t, http.StatusNotModified, serve("If-None-Match", tag).Code)
+	assert.Equal(t, http.StatusOK, serve("If-None-Match", `"stale"`).Code)
+	assert.Equal(t, http.StatusNotModified, serve("If-Modified-Since", "Tue, 05 Nov 2024 12:30:00 GMT").Code)
+
+	// Hiding an entry changes the etag even though nothing was updated
+	assert.NotEqual(t, tag, feedETag("atom", nil))
+}
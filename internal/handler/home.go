@@ -1,65 +1,167 @@
 package handler
 
 import (
+	"errors"
 	"fmt"
 	"html/template"
 	"log/slog"
-	"net"
 	"net/http"
 	"strings"
+	"time"
 
-	goaway "github.com/TwiN/go-away"
 	"github.com/jackc/pgx/v5/pgxpool"
 	// "github.com/x-way/crawlerdetect"
 
-	"github.com/dreamsofcode-io/guestbook/internal/guest"
+	"github.com/dreamsofcode-io/guestbook/internal/ban"
+	"github.com/dreamsofcode-io/guestbook/internal/config"
+	"github.com/dreamsofcode-io/guestbook/internal/csrf"
+	"github.com/dreamsofcode-io/guestbook/internal/edittoken"
+	"github.com/dreamsofcode-io/guestbook/internal/events"
+	"github.com/dreamsofcode-io/guestbook/internal/filter"
+	"github.com/dreamsofcode-io/guestbook/internal/honeypot"
+	"github.com/dreamsofcode-io/guestbook/internal/identicon"
+	"github.com/dreamsofcode-io/guestbook/internal/pow"
+	"github.com/dreamsofcode-io/guestbook/internal/reaction"
 	"github.com/dreamsofcode-io/guestbook/internal/repository"
 )
 
 type Guestbook struct {
-	logger *slog.Logger
-	tmpl   *template.Template
-	repo   *repository.Queries
+	logger     *slog.Logger
+	tmpl       *template.Template
+	repo       *repository.Queries
+	bans       *ban.List
+	pages      *config.Pagination
+	mod        *config.Moderation
+	filters    *filter.Pipeline
+	events     *events.Broker
+	reactions  *reaction.Store
+	threads    *config.Threads
+	markdown   *config.Markdown
+	editing    *config.Editing
+	tokens     *edittoken.Signer
+	identicons *identicon.Generator
+	challenges *pow.Issuer
+	traps      *honeypot.Checker
 }
 
 func New(
 	logger *slog.Logger, db *pgxpool.Pool, tmpl *template.Template,
+	pages *config.Pagination, mod *config.Moderation, filters *filter.Pipeline,
+	broker *events.Broker, reactions *reaction.Store, threads *config.Threads,
+	markdown *config.Markdown, identicons *identicon.Generator,
+	editing *config.Editing, tokens *edittoken.Signer, challenges *pow.Issuer,
+	traps *honeypot.Checker,
 ) *Guestbook {
 	return &Guestbook{
-		tmpl:   tmpl,
-		repo:   repository.New(db),
-		logger: logger,
+		tmpl:       tmpl,
+		repo:       repository.New(db),
+		bans:       ban.New(db),
+		logger:     logger,
+		pages:      pages,
+		mod:        mod,
+		filters:    filters,
+		events:     broker,
+		reactions:  reactions,
+		threads:    threads,
+		markdown:   markdown,
+		identicons: identicons,
+		editing:    editing,
+		tokens:     tokens,
+		challenges: challenges,
+		traps:      traps,
 	}
 }
 
 type indexPage struct {
-	Guests []repository.Guest
-	Total  int64
+	Book   book
+	CSRF   string
+	Guests []entry
+	Query  string
+	// Reactions is the set of reactions offered on entries added live.
+	Reactions []reaction.Count
+	Total     int64
+	Older     string
+	Newer     string
+	Pending   bool
+	// Challenge is the proof of work solved before posting, if one is
+	// needed.
+	Challenge *pow.Challenge
+	// Stamp is the signed time the form was shown.
+	Stamp string
 }
 
 type errorPage struct {
-	ErrorMessage string
+	StatusCode    int
+	StatusMessage string
+	ErrorMessage  string
+}
+
+func (h *Guestbook) renderError(w http.ResponseWriter, statusCode int, message string) {
+	w.WriteHeader(statusCode)
+	h.tmpl.ExecuteTemplate(w, "error.html", errorPage{
+		StatusCode:    statusCode,
+		StatusMessage: http.StatusText(statusCode),
+		ErrorMessage:  message,
+	})
 }
 
 func (h *Guestbook) Home(w http.ResponseWriter, r *http.Request) {
-	guests, err := h.repo.FindAll(r.Context(), 200)
-	if err != nil {
+	b, ok := h.requestBook(w, r)
+	if !ok {
+		return
+	}
+
+	page, err := h.findPage(r.Context(), b.ID, r.URL.Query())
+	if badPageRequest(err) {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	} else if err != nil {
 		h.logger.Error("failed to find guests", slog.Any("error", err))
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 
-	count, err := h.repo.Count(r.Context())
+	if err := h.annotate(r.Context(), page.Guests); err != nil {
+		h.logger.Error("failed to count reactions and replies", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	h.markEditable(r, page.Guests)
+
+	// findPage has already validated the query.
+	query, _ := searchQuery(r.URL.Query())
+
+	count, err := h.count(r.Context(), b.ID, query)
 	if err != nil {
 		h.logger.Error("failed to get count", slog.Any("error", err))
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 
+	var challenge *pow.Challenge
+	if b.Open {
+		challenge, err = h.challenge(r)
+		if err != nil {
+			h.logger.Error("failed to issue challenge", slog.Any("error", err))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+
 	w.Header().Add("Content-Type", "text/html")
 	h.tmpl.ExecuteTemplate(w, "index.html", indexPage{
-		Guests: guests,
-		Total:  count,
+		Book:      b,
+		CSRF:      csrf.Token(r),
+		Guests:    page.Guests,
+		Query:     query,
+		Reactions: reaction.None(),
+		Total:     count,
+		Older:     page.Older,
+		Newer:     page.Newer,
+		Pending:   r.URL.Query().Has("pending"),
+		Challenge: challenge,
+		Stamp:     h.traps.Stamp(time.Now()),
 	})
 }
 
@@ -69,6 +171,11 @@ func (h *Guestbook) Create(w http.ResponseWriter, r *http.Request) {
 	// 	return
 	// }
 	//
+	b, ok := h.requestBook(w, r)
+	if !ok {
+		return
+	}
+
 	if err := r.ParseForm(); err != nil {
 		h.logger.Error("failed to parse form", slog.Any("error", err))
 		w.WriteHeader(http.StatusInternalServerError)
@@ -83,48 +190,74 @@ func (h *Guestbook) Create(w http.ResponseWriter, r *http.Request) {
 
 	message := strings.Join(msg, " ")
 
-	if strings.TrimSpace(message) == "" {
-		w.WriteHeader(http.StatusBadRequest)
-		h.tmpl.ExecuteTemplate(w, "error.html", errorPage{
-			ErrorMessage: "Blank messages don't count",
-		})
+	ipStr, ip := remoteIP(r)
 
+	parent, err := parseParent(r.Form.Get("parent"))
+	if err != nil {
+		h.renderError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	splits := strings.Split(r.RemoteAddr, ":")
-	ipStr := strings.Trim(strings.Join(splits[:len(splits)-1], ":"), "[]")
-	ip := net.ParseIP(ipStr)
-
-	if goaway.IsProfane(message) {
-		w.WriteHeader(http.StatusBadRequest)
-		h.tmpl.ExecuteTemplate(w, "error.html", errorPage{
-			ErrorMessage: fmt.Sprintf(
-				"Please don't use profanity. Your IP has been tracked %s",
-				ipStr,
-			),
-		})
+	if reason := h.traps.Check(r.PostForm, time.Now()); reason != "" {
+		h.discard(w, r, b, parent, ipStr, reason)
 		return
 	}
 
-	guest, err := guest.NewGuest(message, ip)
-	if err != nil {
-		h.logger.Error("failed to create guest", slog.Any("error", err))
-		w.WriteHeader(http.StatusInternalServerError)
+	if !h.checkChallenge(w, r) {
 		return
 	}
 
-	_, err = h.repo.Insert(r.Context(), repository.InsertParams{
-		ID:        guest.ID,
-		Message:   guest.Message,
-		CreatedAt: guest.CreatedAt,
-		Ip:        guest.IP,
+	// The token lets the author change their entry for a while after
+	// posting it.
+	var (
+		token string
+		hash  []byte
+	)
+	if h.editing.Window > 0 {
+		token, hash, err = edittoken.Generate()
+		if err != nil {
+			h.logger.Error("failed to generate edit token", slog.Any("error", err))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+
+	guest, err := h.submit(r.Context(), submission{
+		Book:          b,
+		Message:       message,
+		Name:          r.Form.Get("name"),
+		Website:       r.Form.Get("website"),
+		Parent:        parent,
+		IP:            ip,
+		EditTokenHash: hash,
 	})
-	if err != nil {
-		h.logger.Error("failed to insert guest", slog.Any("error", err))
+
+	var (
+		verr *validationError
+		berr *bannedError
+	)
+	if errors.As(err, &berr) {
+		h.renderError(w, http.StatusForbidden, berr.Error())
+		return
+	} else if errors.As(err, &verr) && verr.Code == "profanity" {
+		h.renderError(w, http.StatusBadRequest, fmt.Sprintf(
+			"Please don't use profanity. Your IP has been tracked %s",
+			ipStr,
+		))
+		return
+	} else if errors.As(err, &verr) {
+		h.renderError(w, http.StatusBadRequest, verr.Message)
+		return
+	} else if err != nil {
+		h.logger.Error("failed to submit guest", slog.Any("error", err))
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 
-	http.Redirect(w, r, "/", http.StatusFound)
+	if token != "" {
+		h.setEditCookie(w, r, guest, token)
+	}
+
+	// Replies go back to the thread they were posted in.
+	http.Redirect(w, r, returnTo(b.Path(), guest), http.StatusFound)
 }
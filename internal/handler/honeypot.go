@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/dreamsofcode-io/guestbook/internal/repository"
+)
+
+// discard drops a submission that looks like it came from a bot. The bot
+// is sent where a real post would go, so it has no reason to try harder,
+// while the discard is logged and counted apart from real posts.
+func (h *Guestbook) discard(
+	w http.ResponseWriter, r *http.Request, b book, parent uuid.UUID, ip, reason string,
+) {
+	h.logger.Info(
+		"discarded bot submission",
+		slog.String("reason", reason),
+		slog.String("clientIP", ip),
+		slog.String("book", b.Slug),
+	)
+
+	if err := h.repo.CountDiscarded(r.Context(), reason); err != nil {
+		h.logger.Error("failed to count discarded submission", slog.Any("error", err))
+	}
+
+	g := repository.Guest{Status: repository.GuestStatusApproved}
+	if h.mod.PreModeration || b.PreModeration {
+		g.Status = repository.GuestStatusPending
+	}
+
+	if parent != uuid.Nil {
+		g.ParentID = pgtype.UUID{Bytes: parent, Valid: true}
+	}
+
+	http.Redirect(w, r, returnTo(b.Path(), g), http.StatusFound)
+}
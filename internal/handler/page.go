@@ -0,0 +1,380 @@
+package handler
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html"
+	"html/template"
+	"net/url"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	"github.com/dreamsofcode-io/guestbook/internal/markdown"
+	"github.com/dreamsofcode-io/guestbook/internal/pagination"
+	"github.com/dreamsofcode-io/guestbook/internal/reaction"
+	"github.com/dreamsofcode-io/guestbook/internal/repository"
+)
+
+// maxQueryLength is the longest search query accepted, in characters.
+const maxQueryLength = 200
+
+var (
+	errConflictingCursors = errors.New("before and after cannot be used together")
+	errSearchAfter        = errors.New("after cannot be used with a search")
+	errQueryTooLong       = fmt.Errorf("search must be at most %d characters", maxQueryLength)
+)
+
+// entry is a guest along with how it is shown in a listing.
+type entry struct {
+	repository.Guest
+	// Headline is the escaped message with search matches wrapped in
+	// <mark>, and Rank how well it matched. Both are only set for search
+	// results.
+	Headline template.HTML
+	Rank     float32
+	// Body is the message rendered as Markdown, set when Markdown is
+	// enabled and the entry isn't a search result.
+	Body template.HTML
+	// Reactions counts every kind of reaction, in display order, and
+	// Replies the published direct replies.
+	Reactions []reaction.Count
+	Replies   int64
+	// Identicon is a data URL of the author's identicon.
+	Identicon template.URL
+	// Editable is true when the visitor wrote the entry and can still
+	// change it.
+	Editable bool
+}
+
+// Edited reports whether the author has changed the entry since posting it.
+func (e entry) Edited() bool {
+	return e.UpdatedAt.After(e.CreatedAt)
+}
+
+// identiconURL turns an identicon into a data URL that can be used as an
+// image source without another request.
+func identiconURL(svg []byte) template.URL {
+	return template.URL("data:image/svg+xml;base64," + base64.StdEncoding.EncodeToString(svg))
+}
+
+func entries(guests []repository.Guest) []entry {
+	res := make([]entry, 0, len(guests))
+	for _, g := range guests {
+		res = append(res, entry{Guest: g})
+	}
+
+	return res
+}
+
+// annotate fills in the reaction and reply counts, the identicon and the
+// rendered body of each entry.
+func (h *Guestbook) annotate(ctx context.Context, guests []entry) error {
+	ids := make([]uuid.UUID, 0, len(guests))
+	for _, g := range guests {
+		ids = append(ids, g.ID)
+	}
+
+	counts, err := h.reactions.Counts(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	replies, err := h.repo.CountReplies(ctx, ids)
+	if err != nil {
+		return fmt.Errorf("count replies: %w", err)
+	}
+
+	replyCounts := make(map[uuid.UUID]int64, len(replies))
+	for _, row := range replies {
+		replyCounts[row.ParentID] = row.Count
+	}
+
+	for i := range guests {
+		guests[i].Reactions = counts[guests[i].ID]
+		guests[i].Replies = replyCounts[guests[i].ID]
+		guests[i].Identicon = identiconURL(h.identicons.SVG(guests[i].Ip))
+
+		if h.markdown != nil && h.markdown.Enabled && guests[i].Headline == "" {
+			guests[i].Body = markdown.Render(guests[i].Message)
+		}
+	}
+
+	return nil
+}
+
+// page is a single window of the guest feed along with the cursors needed
+// to navigate to the neighbouring windows. An empty cursor means there is
+// nothing further in that direction.
+type page struct {
+	Guests []entry
+	Older  string
+	Newer  string
+}
+
+// Markers ts_headline wraps around matches, chosen from the private use area
+// so they are unlikely to appear in messages.
+const (
+	highlightStart = "\uE000"
+	highlightStop  = "\uE001"
+)
+
+// highlight escapes a headline from postgres and turns its markers into
+// <mark> elements. Stray markers in the message can only ever produce
+// balanced, empty-handed <mark> tags.
+func highlight(headline string) template.HTML {
+	var (
+		b    strings.Builder
+		open bool
+	)
+
+	for headline != "" {
+		i := strings.IndexAny(headline, highlightStart+highlightStop)
+		if i < 0 {
+			b.WriteString(html.EscapeString(headline))
+			break
+		}
+
+		b.WriteString(html.EscapeString(headline[:i]))
+
+		r, size := utf8.DecodeRuneInString(headline[i:])
+		if string(r) == highlightStart && !open {
+			b.WriteString("<mark>")
+			open = true
+		} else if string(r) == highlightStop && open {
+			b.WriteString("</mark>")
+			open = false
+		}
+
+		headline = headline[i+size:]
+	}
+
+	if open {
+		b.WriteString("</mark>")
+	}
+
+	return template.HTML(b.String())
+}
+
+// searchQuery returns the q query parameter.
+func searchQuery(query url.Values) (string, error) {
+	q := strings.TrimSpace(query.Get("q"))
+	if utf8.RuneCountInString(q) > maxQueryLength {
+		return "", errQueryTooLong
+	}
+
+	return q, nil
+}
+
+// badPageRequest reports whether the error was caused by the query
+// parameters rather than by loading the page.
+func badPageRequest(err error) bool {
+	return errors.Is(err, pagination.ErrInvalidCursor) ||
+		errors.Is(err, errConflictingCursors) ||
+		errors.Is(err, errSearchAfter) ||
+		errors.Is(err, errQueryTooLong)
+}
+
+// count returns the number of approved entries in a book, not counting
+// replies, or of the guests matching q.
+func (h *Guestbook) count(ctx context.Context, book uuid.UUID, q string) (int64, error) {
+	if q == "" {
+		return h.repo.CountApproved(ctx, book)
+	}
+
+	return h.repo.CountSearch(ctx, repository.CountSearchParams{
+		GuestbookID: book,
+		Query:       q,
+	})
+}
+
+// findPage loads the page of a book's guests described by the q, before,
+// after and limit query parameters.
+func (h *Guestbook) findPage(ctx context.Context, book uuid.UUID, query url.Values) (page, error) {
+	before, after := query.Get("before"), query.Get("after")
+	if before != "" && after != "" {
+		return page{}, errConflictingCursors
+	}
+
+	size := h.pages.Size(query.Get("limit"))
+
+	q, err := searchQuery(query)
+	if err != nil {
+		return page{}, err
+	}
+
+	if q != "" {
+		return h.searchPage(ctx, book, q, before, after, size)
+	}
+
+	// Fetch one extra row to find out whether another page exists.
+	fetch := size + 1
+
+	var (
+		guests   []repository.Guest
+		hasOlder bool
+		hasNewer bool
+	)
+
+	switch {
+	case before != "":
+		cursor, err := pagination.Decode(before)
+		if err != nil {
+			return page{}, err
+		}
+
+		guests, err = h.repo.FindApprovedBefore(ctx, repository.FindApprovedBeforeParams{
+			GuestbookID: book,
+			CreatedAt:   cursor.CreatedAt,
+			ID:          cursor.ID,
+			PageSize:    fetch,
+		})
+		if err != nil {
+			return page{}, fmt.Errorf("find approved before: %w", err)
+		}
+
+		hasOlder = len(guests) > int(size)
+		hasNewer = true
+		guests = guests[:min(len(guests), int(size))]
+	case after != "":
+		cursor, err := pagination.Decode(after)
+		if err != nil {
+			return page{}, err
+		}
+
+		guests, err = h.repo.FindApprovedAfter(ctx, repository.FindApprovedAfterParams{
+			GuestbookID: book,
+			CreatedAt:   cursor.CreatedAt,
+			ID:          cursor.ID,
+			PageSize:    fetch,
+		})
+		if err != nil {
+			return page{}, fmt.Errorf("find approved after: %w", err)
+		}
+
+		hasOlder = true
+		hasNewer = len(guests) > int(size)
+		guests = guests[:min(len(guests), int(size))]
+
+		// Rows come back oldest first, the feed is always newest first.
+		slices.Reverse(guests)
+	default:
+		guests, err = h.repo.FindApproved(ctx, repository.FindApprovedParams{
+			GuestbookID: book,
+			Limit:       fetch,
+		})
+		if err != nil {
+			return page{}, fmt.Errorf("find approved: %w", err)
+		}
+
+		hasOlder = len(guests) > int(size)
+		guests = guests[:min(len(guests), int(size))]
+	}
+
+	res := page{
+		Guests: entries(guests),
+	}
+
+	if len(guests) == 0 {
+		return res, nil
+	}
+
+	if hasOlder {
+		last := guests[len(guests)-1]
+		res.Older = pagination.New(last.CreatedAt, last.ID).Encode()
+	}
+
+	if hasNewer {
+		first := guests[0]
+		res.Newer = pagination.New(first.CreatedAt, first.ID).Encode()
+	}
+
+	return res, nil
+}
+
+// searchPage loads a page of search results, best match first. Results
+// can only be paged forwards.
+func (h *Guestbook) searchPage(
+	ctx context.Context, book uuid.UUID, q, before, after string, size int32,
+) (page, error) {
+	if after != "" {
+		return page{}, errSearchAfter
+	}
+
+	// Fetch one extra row to find out whether another page exists.
+	fetch := size + 1
+
+	var rows []repository.SearchApprovedRow
+
+	if before != "" {
+		cursor, err := pagination.DecodeSearch(before)
+		if err != nil {
+			return page{}, err
+		}
+
+		found, err := h.repo.SearchApprovedBefore(ctx, repository.SearchApprovedBeforeParams{
+			Query:       q,
+			GuestbookID: book,
+			Rank:        cursor.Rank,
+			CreatedAt:   cursor.CreatedAt,
+			ID:          cursor.ID,
+			PageSize:    fetch,
+		})
+		if err != nil {
+			return page{}, fmt.Errorf("search approved before: %w", err)
+		}
+
+		for _, row := range found {
+			rows = append(rows, repository.SearchApprovedRow(row))
+		}
+	} else {
+		var err error
+
+		rows, err = h.repo.SearchApproved(ctx, repository.SearchApprovedParams{
+			Query:       q,
+			GuestbookID: book,
+			PageSize:    fetch,
+		})
+		if err != nil {
+			return page{}, fmt.Errorf("search approved: %w", err)
+		}
+	}
+
+	hasOlder := len(rows) > int(size)
+	rows = rows[:min(len(rows), int(size))]
+
+	res := page{
+		Guests: make([]entry, 0, len(rows)),
+	}
+
+	for _, row := range rows {
+		res.Guests = append(res.Guests, entry{
+			Guest: repository.Guest{
+				ID:        row.ID,
+				Message:   row.Message,
+				Ip:        row.Ip,
+				Name:      row.Name,
+				Website:   row.Website,
+				CreatedAt: row.CreatedAt,
+				UpdatedAt: row.UpdatedAt,
+				Status:    repository.GuestStatusApproved,
+				ParentID:  row.ParentID,
+			},
+			Headline: highlight(row.Headline),
+			Rank:     row.Rank,
+		})
+	}
+
+	if hasOlder {
+		last := rows[len(rows)-1]
+		res.Older = pagination.NewSearch(
+			last.Rank, pagination.New(last.CreatedAt, last.ID),
+		).Encode()
+	}
+
+	return res, nil
+}
@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"html/template"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHighlight(t *testing.T) {
+	testCases := []struct {
+		Description string
+		Headline    string
+		Expected    template.HTML
+	}{
+		{
+			Description: "no matches",
+			Headline:    "hello world",
+			Expected:    "hello world",
+		},
+		{
+			Description: "single match",
+			Headline:    "hello \uE000world\uE001!",
+			Expected:    "hello <mark>world</mark>!",
+		},
+		{
+			Description: "escapes markup",
+			Headline:    "<b>\uE000hi\uE001</b> & bye",
+			Expected:    "&lt;b&gt;<mark>hi</mark>&lt;/b&gt; &amp; bye",
+		},
+		{
+			Description: "unbalanced markers",
+			Headline:    "\uE001a \uE000b \uE000c",
+			Expected:    "a <mark>b c</mark>",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Description, func(t *testing.T) {
+			assert.Equal(t, tc.Expected, highlight(tc.Headline))
+		})
+	}
+}
+
+func TestSearchQuery(t *testing.T) {
+	q, err := searchQuery(url.Values{"q": {"  cats  "}})
+	assert.NoError(t, err)
+	assert.Equal(t, "cats", q)
+
+	_, err = searchQuery(url.Values{"q": {strings.Repeat("é", maxQueryLength+1)}})
+	assert.ErrorIs(t, err, errQueryTooLong)
+}
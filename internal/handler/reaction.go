@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+
+	"github.com/google/uuid"
+
+	"github.com/dreamsofcode-io/guestbook/internal/reaction"
+)
+
+// backTo returns where to send a visitor after a form submission, which is
+// the page they came from when it was on this site.
+func backTo(r *http.Request) string {
+	ref, err := url.Parse(r.Referer())
+	if err != nil || ref.Host != r.Host || ref.Path == "" {
+		return "/"
+	}
+
+	return ref.RequestURI()
+}
+
+// React records a reaction to an entry from the form under each entry.
+func (h *Guestbook) React(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	kind, err := reaction.ParseKind(r.PostFormValue("kind"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	_, ip := remoteIP(r)
+
+	_, err = h.reactions.Add(r.Context(), id, kind, ip)
+	if errors.Is(err, reaction.ErrNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	} else if err != nil {
+		h.logger.Error("failed to add reaction", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, backTo(r), http.StatusFound)
+}
+
+type apiReactRequest struct {
+	Kind string `json:"kind"`
+}
+
+type apiReactions struct {
+	Added     bool             `json:"added"`
+	Reactions map[string]int64 `json:"reactions"`
+}
+
+func newAPIReactions(counts []reaction.Count) map[string]int64 {
+	res := make(map[string]int64, len(counts))
+	for _, c := range counts {
+		res[string(c.Kind)] = c.Count
+	}
+
+	return res
+}
+
+// React records a reaction to a guest and returns its updated counts.
+func (a *API) React(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		a.writeError(w, http.StatusBadRequest, "invalid_id", "Invalid guest id")
+		return
+	}
+
+	var req apiReactRequest
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBody)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		a.writeError(w, http.StatusBadRequest, "invalid_body", "Invalid request body")
+		return
+	}
+
+	kind, err := reaction.ParseKind(req.Kind)
+	if err != nil {
+		a.writeError(w, http.StatusBadRequest, "invalid_reaction", "Unknown reaction")
+		return
+	}
+
+	_, ip := remoteIP(r)
+
+	added, err := a.guestbook.reactions.Add(r.Context(), id, kind, ip)
+	if errors.Is(err, reaction.ErrNotFound) {
+		a.writeError(w, http.StatusNotFound, "not_found", "Guest not found")
+		return
+	} else if err != nil {
+		a.logger.Error("failed to add reaction", slog.Any("error", err))
+		a.internalError(w)
+		return
+	}
+
+	counts, err := a.guestbook.reactions.Counts(r.Context(), []uuid.UUID{id})
+	if err != nil {
+		a.logger.Error("failed to count reactions", slog.Any("error", err))
+		a.internalError(w)
+		return
+	}
+
+	a.writeJSON(w, http.StatusOK, apiReactions{
+		Added:     added,
+		Reactions: newAPIReactions(counts[id]),
+	})
+}
@@ -0,0 +1,323 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+	"github.com/google/uuid"
+
+	"github.com/dreamsofcode-io/guestbook/internal/books"
+	"github.com/dreamsofcode-io/guestbook/internal/config"
+	"github.com/dreamsofcode-io/guestbook/internal/events"
+	"github.com/dreamsofcode-io/guestbook/internal/middleware"
+	"github.com/dreamsofcode-io/guestbook/internal/repository"
+)
+
+// socketWriteTimeout bounds each write so a stalled client can't hold up
+// the connection.
+const socketWriteTimeout = 5 * time.Second
+
+// Socket serves the websocket interface. Clients receive entries as they
+// are published and may submit messages over the same connection, which are
+// validated like any other submission.
+type Socket struct {
+	guestbook *Guestbook
+	logger    *slog.Logger
+	heartbeat time.Duration
+	cfg       *config.WebSocket
+	// limiter applies the same limit as posting the form. It may be nil.
+	limiter *middleware.RateLimiter
+	// mu guards closing, which is set once the server starts shutting
+	// down so no connection is added to conns while it is waited on.
+	mu      sync.Mutex
+	closing bool
+	conns   sync.WaitGroup
+}
+
+func NewSocket(
+	guestbook *Guestbook, eventsCfg *config.Events, cfg *config.WebSocket,
+	limiter *middleware.RateLimiter,
+) *Socket {
+	return &Socket{
+		guestbook: guestbook,
+		logger:    guestbook.logger,
+		heartbeat: eventsCfg.Heartbeat,
+		cfg:       cfg,
+		limiter:   limiter,
+	}
+}
+
+// socketRequest is a message sent by the client. The only type is
+// "submit", which carries the same solved challenge as a submission to the
+// API.
+type socketRequest struct {
+	Type     string    `json:"type"`
+	Message  string    `json:"message"`
+	Name     string    `json:"name"`
+	Website  string    `json:"website"`
+	ParentID uuid.UUID `json:"parent_id"`
+	proof
+}
+
+// socketMessage is a message sent to the client: "guest" when an entry is
+// published, "created" in reply to a submission, or "error".
+type socketMessage struct {
+	Type  string    `json:"type"`
+	Guest any       `json:"guest,omitempty"`
+	Error *apiError `json:"error,omitempty"`
+}
+
+func socketError(code, message string) socketMessage {
+	return socketMessage{
+		Type: "error",
+		Error: &apiError{
+			Code:    code,
+			Message: message,
+		},
+	}
+}
+
+// messageLimiter limits how many messages a single connection may send in
+// each minute.
+type messageLimiter struct {
+	limit int
+	start time.Time
+	count int
+}
+
+func (l *messageLimiter) allow(now time.Time) bool {
+	if now.Sub(l.start) >= time.Minute {
+		l.start = now
+		l.count = 0
+	}
+
+	l.count++
+
+	return l.count <= l.limit
+}
+
+func (s *Socket) write(ctx context.Context, conn *websocket.Conn, msg socketMessage) error {
+	ctx, cancel := context.WithTimeout(ctx, socketWriteTimeout)
+	defer cancel()
+
+	return wsjson.Write(ctx, conn, msg)
+}
+
+// track counts a new connection, unless the server is shutting down.
+func (s *Socket) track() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closing {
+		return false
+	}
+
+	s.conns.Add(1)
+
+	return true
+}
+
+// Serve upgrades the request to a websocket for a book. Connections count
+// towards the same per-client cap as the event stream. Like the stream, the
+// book isn't looked up until a message is submitted.
+func (s *Socket) Serve(w http.ResponseWriter, r *http.Request) {
+	slug := bookSlug(r)
+
+	// The connection is counted before upgrading, so shutting down waits
+	// for it however far it has got.
+	if !s.track() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	defer s.conns.Done()
+
+	sub, err := s.guestbook.events.Subscribe(subscriber(r))
+	if errors.Is(err, events.ErrTooManyConnections) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		return
+	} else if err != nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	defer sub.Close()
+
+	// Accept refuses cross origin requests unless they match a pattern
+	conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{
+		OriginPatterns: s.cfg.AllowedOrigins,
+	})
+	if err != nil {
+		return
+	}
+	defer conn.CloseNow()
+
+	conn.SetReadLimit(maxRequestBody)
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	go func() {
+		defer cancel()
+		s.read(ctx, conn, r, slug)
+	}()
+
+	ticker := time.NewTicker(s.heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			pingCtx, cancelPing := context.WithTimeout(ctx, s.heartbeat)
+			err := conn.Ping(pingCtx)
+			cancelPing()
+
+			if err != nil {
+				return
+			}
+		case e, ok := <-sub.Events():
+			if !ok {
+				conn.Close(websocket.StatusGoingAway, "reconnect")
+				return
+			}
+
+			if e.Book != eventBook(slug) {
+				continue
+			}
+
+			err := s.write(ctx, conn, socketMessage{
+				Type:  "guest",
+				Guest: json.RawMessage(e.Data),
+			})
+			if err != nil {
+				return
+			}
+		}
+	}
+}
+
+// read handles messages from the client until the connection ends.
+// Messages are added to the book the connection is for.
+func (s *Socket) read(ctx context.Context, conn *websocket.Conn, r *http.Request, slug string) {
+	limiter := &messageLimiter{
+		limit: s.cfg.MessagesPerMinute,
+	}
+
+	for {
+		_, data, err := conn.Read(ctx)
+		if err != nil {
+			return
+		}
+
+		var reply socketMessage
+
+		var req socketRequest
+		if !limiter.allow(time.Now()) {
+			reply = socketError("rate_limited", "Too many messages, slow down")
+		} else if err := json.Unmarshal(data, &req); err != nil {
+			reply = socketError("invalid_body", "Invalid message")
+		} else if req.Type == "submit" {
+			reply = s.submit(ctx, r, slug, req)
+		} else {
+			reply = socketError("invalid_type", fmt.Sprintf("Unknown message type %q", req.Type))
+		}
+
+		if err := s.write(ctx, conn, reply); err != nil {
+			return
+		}
+	}
+}
+
+// submit stores a message sent over the socket, applying the same rate limit
+// as posting the form and the proof of work when POW_API is set.
+func (s *Socket) submit(
+	ctx context.Context, r *http.Request, slug string, req socketRequest,
+) socketMessage {
+	if s.limiter != nil {
+		res, err := s.limiter.Allow(r)
+		if err != nil && s.limiter.FailClosed {
+			return socketError("unavailable", unavailableMessage)
+		} else if err == nil && !res.Allowed {
+			return socketError("rate_limited", fmt.Sprintf(
+				"You're posting too quickly, try again in %s",
+				res.RetryAfter.Round(time.Second),
+			))
+		}
+	}
+
+	// The book is looked up for every message, as its settings may change
+	// while the connection is open.
+	b, err := s.guestbook.findBook(ctx, slug)
+	if errors.Is(err, books.ErrNotFound) {
+		return socketError("guestbook_not_found", "Guestbook not found")
+	} else if err != nil {
+		s.logger.Error("failed to find guestbook", slog.Any("error", err))
+		return socketError("internal_error", "Something went wrong")
+	}
+
+	_, ip := remoteIP(r)
+
+	sub := submission{
+		Book:    b,
+		Message: req.Message,
+		Name:    req.Name,
+		Website: req.Website,
+		Parent:  req.ParentID,
+		IP:      ip,
+	}
+
+	err = s.guestbook.verifyAPIChallenge(ctx, req.proof)
+
+	var g repository.Guest
+	if err == nil {
+		g, err = s.guestbook.submit(ctx, sub)
+	}
+
+	var (
+		verr *validationError
+		berr *bannedError
+	)
+	if errors.Is(err, errUnavailable) {
+		return socketError("unavailable", unavailableMessage)
+	} else if errors.As(err, &berr) {
+		return socketError("banned", berr.Error())
+	} else if errors.As(err, &verr) {
+		return socketError(verr.Code, verr.Message)
+	} else if err != nil {
+		s.logger.Error("failed to submit guest", slog.Any("error", err))
+		return socketError("internal_error", "Something went wrong")
+	}
+
+	return socketMessage{
+		Type:  "created",
+		Guest: newAPIGuest(g),
+	}
+}
+
+// Wait refuses new connections and blocks until every open one has closed,
+// or the context is done. Connections close once the broker does, when the
+// server shuts down.
+func (s *Socket) Wait(ctx context.Context) {
+	s.mu.Lock()
+	s.closing = true
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.conns.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+}
@@ -0,0 +1,124 @@
+package handler_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dreamsofcode-io/guestbook/internal/config"
+	"github.com/dreamsofcode-io/guestbook/internal/events"
+	"github.com/dreamsofcode-io/guestbook/internal/handler"
+	"github.com/dreamsofcode-io/guestbook/internal/repository"
+)
+
+type socketMessage struct {
+	Type  string         `json:"type"`
+	Guest map[string]any `json:"guest"`
+	Error struct {
+		Code string `json:"code"`
+	} `json:"error"`
+}
+
+func newSocketServer(t *testing.T, broker *events.Broker) (*handler.Socket, string) {
+	t.Helper()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	guestbook := handler.New(logger, nil, nil, nil, nil, nil, broker, nil, nil, nil, nil, nil, nil, nil, nil)
+
+	socket := handler.NewSocket(
+		guestbook,
+		&config.Events{Heartbeat: time.Minute},
+		&config.WebSocket{MessagesPerMinute: 2},
+		nil,
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(socket.Serve))
+	t.Cleanup(srv.Close)
+
+	return socket, "ws" + strings.TrimPrefix(srv.URL, "http")
+}
+
+func TestSocket(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	broker := events.NewBroker(1)
+	socket, url := newSocketServer(t, broker)
+
+	conn, _, err := websocket.Dial(ctx, url, nil)
+	require.NoError(t, err)
+	defer conn.CloseNow()
+
+	// The connection counts towards the per-client cap
+	_, res, err := websocket.Dial(ctx, url, nil)
+	require.Error(t, err)
+	assert.Equal(t, http.StatusTooManyRequests, res.StatusCode)
+
+	require.Eventually(t, func() bool { return broker.Subscribers() == 1 }, time.Second, 10*time.Millisecond)
+
+	broker.Publish(events.NewGuestEvent(repository.Guest{Message: "hello"}))
+
+	var msg socketMessage
+	require.NoError(t, wsjson.Read(ctx, conn, &msg))
+	assert.Equal(t, "guest", msg.Type)
+	assert.Equal(t, "hello", msg.Guest["message"])
+
+	require.NoError(t, conn.Write(ctx, websocket.MessageText, []byte("not json")))
+	require.NoError(t, wsjson.Read(ctx, conn, &msg))
+	assert.Equal(t, "invalid_body", msg.Error.Code)
+
+	require.NoError(t, wsjson.Write(ctx, conn, map[string]string{"type": "dance"}))
+	require.NoError(t, wsjson.Read(ctx, conn, &msg))
+	assert.Equal(t, "invalid_type", msg.Error.Code)
+
+	// The connection is limited to two messages a minute
+	require.NoError(t, wsjson.Write(ctx, conn, map[string]string{"type": "dance"}))
+	require.NoError(t, wsjson.Read(ctx, conn, &msg))
+	assert.Equal(t, "rate_limited", msg.Error.Code)
+
+	// Closing the broker closes the connection
+	broker.Close()
+
+	_, _, err = conn.Read(ctx)
+	assert.Equal(t, websocket.StatusGoingAway, websocket.CloseStatus(err))
+
+	socket.Wait(ctx)
+	assert.NoError(t, ctx.Err())
+}
+
+func TestSocketOrigin(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, url := newSocketServer(t, events.NewBroker(0))
+
+	_, res, err := websocket.Dial(ctx, url, &websocket.DialOptions{
+		HTTPHeader: http.Header{"Origin": []string{"https://evil.example"}},
+	})
+	require.Error(t, err)
+	assert.Equal(t, http.StatusForbidden, res.StatusCode)
+}
+
+func TestSocketShutdown(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	socket, url := newSocketServer(t, events.NewBroker(0))
+
+	// Once shutting down, no new connections are upgraded
+	socket.Wait(ctx)
+
+	_, res, err := websocket.Dial(ctx, url, nil)
+	require.Error(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+}
@@ -0,0 +1,155 @@
+package handler
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/dreamsofcode-io/guestbook/internal/books"
+	"github.com/dreamsofcode-io/guestbook/internal/clientip"
+	"github.com/dreamsofcode-io/guestbook/internal/config"
+	"github.com/dreamsofcode-io/guestbook/internal/events"
+	"github.com/dreamsofcode-io/guestbook/internal/repository"
+)
+
+const (
+	// replayLimit is the most missed entries sent when a client resumes.
+	replayLimit = 100
+	// retryAfter is how long browsers wait before reconnecting.
+	retryAfter = 5 * time.Second
+)
+
+// Stream serves new entries as server-sent events.
+type Stream struct {
+	guestbook *Guestbook
+	logger    *slog.Logger
+	heartbeat time.Duration
+}
+
+func NewStream(guestbook *Guestbook, cfg *config.Events) *Stream {
+	return &Stream{
+		guestbook: guestbook,
+		logger:    guestbook.logger,
+		heartbeat: cfg.Heartbeat,
+	}
+}
+
+func writeEvent(w io.Writer, e events.Event) error {
+	_, err := fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", e.ID, e.Name, e.Data)
+	return err
+}
+
+// lastEventID returns the ID the client last received, from the header
+// browsers send when reconnecting, or the query string for the first
+// connection.
+func lastEventID(r *http.Request) (uuid.UUID, bool) {
+	value := r.Header.Get("Last-Event-ID")
+	if value == "" {
+		value = r.URL.Query().Get("last_event_id")
+	}
+
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return uuid.Nil, false
+	}
+
+	return id, true
+}
+
+// subscriber is who a connection counts towards in the per-client cap: the
+// client's subnet, so an IPv6 client can't get around the cap by picking
+// another address from its range.
+func subscriber(r *http.Request) string {
+	return clientip.Subnet(clientip.IP(clientip.FromRequest(r))).String()
+}
+
+// Events streams the entries of a book as they are published. Clients that
+// send a Last-Event-ID first receive the entries they missed. The book isn't
+// looked up, so an unknown book just never has any entries.
+func (s *Stream) Events(w http.ResponseWriter, r *http.Request) {
+	slug := bookSlug(r)
+
+	sub, err := s.guestbook.events.Subscribe(subscriber(r))
+	if errors.Is(err, events.ErrTooManyConnections) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		return
+	} else if err != nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	defer sub.Close()
+
+	rc := http.NewResponseController(w)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprintf(w, "retry: %d\n\n", retryAfter.Milliseconds())
+
+	// Subscribing before replaying means nothing published in between is
+	// lost, but it may arrive twice.
+	replayed := map[string]bool{}
+
+	if last, ok := lastEventID(r); ok {
+		guests, err := s.guestbook.repo.FindGuestbookApprovedSinceID(r.Context(), repository.FindGuestbookApprovedSinceIDParams{
+			Slug:     cmp.Or(slug, books.Default),
+			ID:       last,
+			PageSize: replayLimit,
+		})
+		if err != nil {
+			s.logger.Error("failed to replay events", slog.Any("error", err))
+			return
+		}
+
+		for _, g := range guests {
+			if err := writeEvent(w, events.NewGuestEvent(g)); err != nil {
+				return
+			}
+
+			replayed[g.ID.String()] = true
+		}
+	}
+
+	if err := rc.Flush(); err != nil {
+		s.logger.Error("streaming is not supported", slog.Any("error", err))
+		return
+	}
+
+	ticker := time.NewTicker(s.heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+			if _, err := io.WriteString(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		case e, ok := <-sub.Events():
+			if !ok {
+				return
+			}
+
+			if e.Book != eventBook(slug) || replayed[e.ID] {
+				continue
+			}
+
+			if err := writeEvent(w, e); err != nil {
+				return
+			}
+		}
+
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubscriber(t *testing.T) {
+	client := func(remoteAddr string) string {
+		req := httptest.NewRequest(http.MethodGet, "/events", nil)
+		req.RemoteAddr = remoteAddr
+
+		return subscriber(req)
+	}
+
+	assert.Equal(t, "192.0.2.1", client("192.0.2.1:1234"))
+
+	// Addresses in the same /64 count as one client
+	assert.Equal(t, client("[2001:db8::1]:1234"), client("[2001:db8::ffff:1]:1234"))
+	assert.NotEqual(t, client("[2001:db8::1]:1234"), client("[2001:db8:0:1::1]:1234"))
+}
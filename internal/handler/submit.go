@@ -0,0 +1,271 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/dreamsofcode-io/guestbook/internal/books"
+	"github.com/dreamsofcode-io/guestbook/internal/clientip"
+	"github.com/dreamsofcode-io/guestbook/internal/filter"
+	"github.com/dreamsofcode-io/guestbook/internal/guest"
+	"github.com/dreamsofcode-io/guestbook/internal/repository"
+)
+
+// validationError is returned when a message is refused. The code is a
+// stable identifier for API clients, the message is shown to visitors.
+type validationError struct {
+	Code    string
+	Message string
+}
+
+func (e *validationError) Error() string {
+	return e.Message
+}
+
+// bannedError is returned when the client's IP address is banned.
+type bannedError struct {
+	Reason string
+}
+
+func (e *bannedError) Error() string {
+	if e.Reason == "" {
+		return "Your IP address has been banned from posting"
+	}
+
+	return fmt.Sprintf("Your IP address has been banned from posting: %s", e.Reason)
+}
+
+var errBlankMessage = &validationError{
+	Code:    "blank_message",
+	Message: "Blank messages don't count",
+}
+
+var (
+	errParentNotFound = &validationError{
+		Code:    "parent_not_found",
+		Message: "The message you're replying to doesn't exist",
+	}
+	errTooDeep = &validationError{
+		Code:    "too_deep",
+		Message: "Replies can't be nested any deeper",
+	}
+)
+
+// submission is a message sent through one of the interfaces that accept
+// them.
+type submission struct {
+	// Book is the guestbook new threads are added to. Replies are added
+	// to the book of the entry they reply to.
+	Book    book
+	Message string
+	// Name and Website are the author's optional display name and link.
+	Name    string
+	Website string
+	// Parent is the entry being replied to, or uuid.Nil for a new thread.
+	Parent uuid.UUID
+	IP     net.IP
+	// EditTokenHash is the hash of the token that lets the author change
+	// the entry, or nil if they can't.
+	EditTokenHash []byte
+}
+
+// parseParent parses the id of the entry being replied to, which may be
+// empty.
+func parseParent(s string) (uuid.UUID, error) {
+	if s == "" {
+		return uuid.Nil, nil
+	}
+
+	id, err := uuid.Parse(s)
+	if err != nil {
+		return uuid.Nil, errParentNotFound
+	}
+
+	return id, nil
+}
+
+// validateMessage checks the message isn't blank or too long for the book
+// and runs it through the content filters. It returns the message to store,
+// which a filter may have rewritten, and whether it should be held for
+// review. Messages are never let through longer than the database stores,
+// whatever the book and filters allow.
+func (h *Guestbook) validateMessage(b book, message string) (string, bool, error) {
+	if strings.TrimSpace(message) == "" {
+		return "", false, errBlankMessage
+	}
+
+	maxLength := books.MaxMessageLength
+	if b.MaxLength > 0 && int(b.MaxLength) < maxLength {
+		maxLength = int(b.MaxLength)
+	}
+
+	if utf8.RuneCountInString(message) > maxLength {
+		return "", false, &validationError{
+			Code:    "max_length",
+			Message: fmt.Sprintf("Messages can be at most %d characters", maxLength),
+		}
+	}
+
+	res := h.filters.Run(message)
+	if res.Action == filter.Reject {
+		return "", false, &validationError{
+			Code:    res.Filter,
+			Message: res.Reason,
+		}
+	}
+
+	return res.Message, res.Action == filter.Hold, nil
+}
+
+// remoteIP returns the client IP resolved from the trusted proxy chain.
+func remoteIP(r *http.Request) (string, net.IP) {
+	addr := clientip.FromRequest(r)
+
+	return addr.String(), clientip.IP(addr)
+}
+
+// validateAuthor checks the optional display name and website, returning
+// them in the form they are stored. Names go through the same content
+// filters as messages, so they may be rewritten or held for review too.
+func (h *Guestbook) validateAuthor(name, website string) (string, string, bool, error) {
+	name, err := guest.ParseName(name)
+	if errors.Is(err, guest.ErrNameTooLong) {
+		return "", "", false, &validationError{
+			Code:    "name_too_long",
+			Message: fmt.Sprintf("Names can be at most %d characters", guest.MaxNameLength),
+		}
+	} else if err != nil {
+		return "", "", false, &validationError{
+			Code:    "invalid_name",
+			Message: "Names can't contain control characters",
+		}
+	}
+
+	var held bool
+	if name != "" {
+		res := h.filters.Run(name)
+		if res.Action == filter.Reject {
+			return "", "", false, &validationError{
+				Code:    "name_" + res.Filter,
+				Message: "This name isn't allowed, please choose another",
+			}
+		}
+
+		name, held = res.Message, res.Action == filter.Hold
+	}
+
+	website, err = guest.ParseWebsite(website)
+	if err != nil {
+		return "", "", false, &validationError{
+			Code:    "invalid_website",
+			Message: "Websites must be http or https addresses",
+		}
+	}
+
+	return name, website, held, nil
+}
+
+// replyTo returns the book and depth of a reply to the given entry, or the
+// given book for a new thread. Replies can only be made to published
+// entries that aren't already nested too deeply.
+func (h *Guestbook) replyTo(ctx context.Context, b book, parent uuid.UUID) (book, int32, error) {
+	if parent == uuid.Nil {
+		return b, 0, nil
+	}
+
+	g, err := h.repo.FindByID(ctx, parent)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && g.Status != repository.GuestStatusApproved) {
+		return book{}, 0, errParentNotFound
+	} else if err != nil {
+		return book{}, 0, fmt.Errorf("failed to find parent: %w", err)
+	}
+
+	if int(g.Depth) >= h.threads.MaxDepth {
+		return book{}, 0, errTooDeep
+	}
+
+	if g.GuestbookID != b.ID {
+		b, err = h.bookByID(ctx, g.GuestbookID)
+		if err != nil {
+			return book{}, 0, err
+		}
+	}
+
+	return b, g.Depth + 1, nil
+}
+
+// submit validates and stores a new message. It is shared by every
+// interface that accepts messages so they all apply the same rules.
+func (h *Guestbook) submit(
+	ctx context.Context, sub submission,
+) (repository.Guest, error) {
+	ban, banned, err := h.bans.Check(ctx, sub.IP)
+	if err != nil {
+		return repository.Guest{}, fmt.Errorf("failed to check ban: %w", err)
+	}
+
+	if banned {
+		return repository.Guest{}, &bannedError{Reason: ban.Reason}
+	}
+
+	b, depth, err := h.replyTo(ctx, sub.Book, sub.Parent)
+	if err != nil {
+		return repository.Guest{}, err
+	}
+
+	if !b.Open {
+		return repository.Guest{}, errClosed
+	}
+
+	message, held, err := h.validateMessage(b, sub.Message)
+	if err != nil {
+		return repository.Guest{}, err
+	}
+
+	name, website, heldName, err := h.validateAuthor(sub.Name, sub.Website)
+	if err != nil {
+		return repository.Guest{}, err
+	}
+
+	guest, err := guest.NewGuest(message, sub.IP)
+	if err != nil {
+		return repository.Guest{}, fmt.Errorf("failed to create guest: %w", err)
+	}
+
+	guest.Name = name
+	guest.Website = website
+
+	status := repository.GuestStatusApproved
+	if h.mod.PreModeration || b.PreModeration || held || heldName {
+		status = repository.GuestStatusPending
+	}
+
+	res, err := h.repo.Insert(ctx, repository.InsertParams{
+		ID:        guest.ID,
+		Message:   guest.Message,
+		CreatedAt: guest.CreatedAt,
+		Ip:        guest.IP,
+		Status:    status,
+		ParentID:  pgtype.UUID{Bytes: sub.Parent, Valid: sub.Parent != uuid.Nil},
+		Depth:     depth,
+		Name:      guest.Name,
+		Website:   guest.Website,
+
+		EditTokenHash: sub.EditTokenHash,
+		GuestbookID:   b.ID,
+	})
+	if err != nil {
+		return repository.Guest{}, fmt.Errorf("failed to insert guest: %w", err)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dreamsofcode-io/guestbook/internal/filter"
+	"github.com/dreamsofcode-io/guestbook/internal/repository"
+)
+
+func TestValidateMessageLength(t *testing.T) {
+	// No max_length filter is configured, so only the book and the
+	// database limit apply.
+	h := &Guestbook{filters: filter.NewPipeline(slog.Default())}
+
+	testCases := []struct {
+		Description string
+		MaxLength   int32
+		Message     string
+		ExpectErr   bool
+	}{
+		{
+			Description: "within the database limit",
+			Message:     strings.Repeat("a", 256),
+		},
+		{
+			Description: "longer than the database stores",
+			Message:     strings.Repeat("a", 257),
+			ExpectErr:   true,
+		},
+		{
+			Description: "longer than the book allows",
+			MaxLength:   10,
+			Message:     strings.Repeat("a", 11),
+			ExpectErr:   true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Description, func(t *testing.T) {
+			b := book{repository.Guestbook{MaxLength: tc.MaxLength}}
+
+			_, _, err := h.validateMessage(b, tc.Message)
+			if tc.ExpectErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestValidateAuthorFilters(t *testing.T) {
+	testCases := []struct {
+		Description string
+		Filters     []filter.ContentFilter
+		Name        string
+		Expected    string
+		Code        string
+	}{
+		{
+			Description: "no filters",
+			Name:        "shit",
+			Expected:    "shit",
+		},
+		{
+			Description: "rejected",
+			Filters:     []filter.ContentFilter{&filter.Profanity{}},
+			Name:        "shit",
+			Code:        "name_profanity",
+		},
+		{
+			Description: "rewritten",
+			Filters:     []filter.ContentFilter{&filter.Profanity{Censor: true}},
+			Name:        "shit",
+			Expected:    "****",
+		},
+		{
+			Description: "blank names aren't filtered",
+			Filters:     []filter.ContentFilter{&filter.MaxLength{Max: 0}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Description, func(t *testing.T) {
+			h := &Guestbook{filters: filter.NewPipeline(slog.Default(), tc.Filters...)}
+
+			name, _, held, err := h.validateAuthor(tc.Name, "")
+			if tc.Code != "" {
+				var verr *validationError
+				assert.ErrorAs(t, err, &verr)
+				assert.Equal(t, tc.Code, verr.Code)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.False(t, held)
+			assert.Equal(t, tc.Expected, name)
+		})
+	}
+}
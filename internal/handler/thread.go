@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/dreamsofcode-io/guestbook/internal/csrf"
+	"github.com/dreamsofcode-io/guestbook/internal/pow"
+	"github.com/dreamsofcode-io/guestbook/internal/repository"
+)
+
+// threadSize is the most entries shown on a thread's page.
+const threadSize = 500
+
+// threadNode is an entry in a thread along with its published replies.
+type threadNode struct {
+	entry
+	Children []*threadNode
+	// CanReply is false once the entry is nested as deeply as allowed.
+	CanReply bool
+}
+
+type threadPage struct {
+	Book book
+	Root *threadNode
+	// Parent is the entry the root replies to, if it is a reply.
+	Parent    *uuid.UUID
+	Pending   bool
+	CSRF      string
+	Challenge *pow.Challenge
+	// Stamp is the signed time the reply forms were shown.
+	Stamp string
+}
+
+// threadView is what the thread-node template renders: a node along with
+// the page it is on, so the node's forms reach the page's token and stamp
+// through $.
+type threadView struct {
+	*threadPage
+	Node *threadNode
+}
+
+// View returns the node as the thread-node template renders it.
+func (p *threadPage) View(node *threadNode) threadView {
+	return threadView{
+		threadPage: p,
+		Node:       node,
+	}
+}
+
+// buildThread arranges entries, ordered by depth, into a tree below the
+// first one.
+func buildThread(guests []entry, maxDepth int) *threadNode {
+	if len(guests) == 0 {
+		return nil
+	}
+
+	nodes := make(map[uuid.UUID]*threadNode, len(guests))
+	for _, g := range guests {
+		nodes[g.ID] = &threadNode{
+			entry:    g,
+			CanReply: int(g.Depth) < maxDepth,
+		}
+	}
+
+	root := nodes[guests[0].ID]
+
+	for _, g := range guests[1:] {
+		parent, ok := nodes[uuid.UUID(g.ParentID.Bytes)]
+		if !g.ParentID.Valid || !ok {
+			continue
+		}
+
+		parent.Children = append(parent.Children, nodes[g.ID])
+	}
+
+	return root
+}
+
+// Thread shows an entry along with every published reply below it.
+func (h *Guestbook) Thread(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	guests, err := h.repo.FindThread(r.Context(), repository.FindThreadParams{
+		ID:       id,
+		PageSize: threadSize,
+	})
+	if err != nil {
+		h.logger.Error("failed to find thread", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if len(guests) == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	thread := entries(guests)
+	if err := h.annotate(r.Context(), thread); err != nil {
+		h.logger.Error("failed to count reactions and replies", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	h.markEditable(r, thread)
+
+	b, err := h.bookByID(r.Context(), guests[0].GuestbookID)
+	if err != nil {
+		h.logger.Error("failed to find guestbook", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	challenge, err := h.challenge(r)
+	if err != nil {
+		h.logger.Error("failed to issue challenge", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	data := &threadPage{
+		Book:      b,
+		Root:      buildThread(thread, h.threads.MaxDepth),
+		Pending:   r.URL.Query().Has("pending"),
+		CSRF:      csrf.Token(r),
+		Challenge: challenge,
+		Stamp:     h.traps.Stamp(time.Now()),
+	}
+
+	if root := guests[0]; root.ParentID.Valid {
+		parent := uuid.UUID(root.ParentID.Bytes)
+		data.Parent = &parent
+	}
+
+	w.Header().Add("Content-Type", "text/html")
+	h.tmpl.ExecuteTemplate(w, "thread.html", data)
+}
+
+// Identicon serves the identicon of an entry's author. Pages embed them
+// directly, this is for entries added by script and for API clients.
+func (h *Guestbook) Identicon(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	g, err := h.repo.FindByID(r.Context(), id)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && g.Status != repository.GuestStatusApproved) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	} else if err != nil {
+		h.logger.Error("failed to find guest", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/svg+xml")
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	w.Write(h.identicons.SVG(g.Ip))
+}
@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dreamsofcode-io/guestbook/internal/repository"
+)
+
+func TestBuildThread(t *testing.T) {
+	reply := func(parent uuid.UUID, depth int32) entry {
+		return entry{Guest: repository.Guest{
+			ID:       uuid.New(),
+			ParentID: pgtype.UUID{Bytes: parent, Valid: true},
+			Depth:    depth,
+		}}
+	}
+
+	root := entry{Guest: repository.Guest{ID: uuid.New(), Depth: 1}}
+	first := reply(root.ID, 2)
+	second := reply(root.ID, 2)
+	nested := reply(first.ID, 3)
+	orphan := reply(uuid.New(), 3)
+
+	thread := buildThread([]entry{root, first, second, nested, orphan}, 2)
+	require.NotNil(t, thread)
+
+	assert.Equal(t, root.ID, thread.ID)
+	assert.True(t, thread.CanReply)
+	require.Len(t, thread.Children, 2)
+	assert.Equal(t, first.ID, thread.Children[0].ID)
+	assert.Equal(t, second.ID, thread.Children[1].ID)
+	assert.False(t, thread.Children[0].CanReply)
+	require.Len(t, thread.Children[0].Children, 1)
+	assert.Equal(t, nested.ID, thread.Children[0].Children[0].ID)
+
+	assert.Nil(t, buildThread(nil, 2))
+}
@@ -0,0 +1,88 @@
+// Package honeypot spots bots by how they fill in the form: they fill in a
+// field people never see, or post faster than anyone could type. Forms
+// carry a signed timestamp of when they were shown, so the timing can't be
+// faked.
+package honeypot
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dreamsofcode-io/guestbook/internal/config"
+)
+
+const (
+	// Field is the hidden input people leave empty. It has a name bots
+	// like to fill in, but browsers won't autofill.
+	Field = "subject"
+	// StampField carries the signed time the form was shown.
+	StampField = "rendered"
+)
+
+// Reasons a submission is discarded.
+const (
+	ReasonHoneypot = "honeypot"
+	ReasonTooFast  = "too_fast"
+	ReasonExpired  = "expired"
+	ReasonNoStamp  = "invalid_stamp"
+)
+
+// Checker stamps forms and checks the submissions made with them.
+type Checker struct {
+	key []byte
+	cfg *config.Honeypot
+}
+
+func New(key []byte, cfg *config.Honeypot) *Checker {
+	return &Checker{
+		key: key,
+		cfg: cfg,
+	}
+}
+
+func (c *Checker) sign(stamp string) string {
+	mac := hmac.New(sha256.New, c.key)
+	mac.Write([]byte("form:"))
+	mac.Write([]byte(stamp))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Stamp returns the signed timestamp for a form shown at now.
+func (c *Checker) Stamp(now time.Time) string {
+	stamp := strconv.FormatInt(now.UnixMilli(), 10)
+	return stamp + "." + c.sign(stamp)
+}
+
+// Check returns why a submission looks like it came from a bot, or an
+// empty string when it doesn't.
+func (c *Checker) Check(form url.Values, now time.Time) string {
+	if form.Get(Field) != "" {
+		return ReasonHoneypot
+	}
+
+	stamp, sig, ok := strings.Cut(form.Get(StampField), ".")
+	if !ok || !hmac.Equal([]byte(sig), []byte(c.sign(stamp))) {
+		return ReasonNoStamp
+	}
+
+	millis, err := strconv.ParseInt(stamp, 10, 64)
+	if err != nil {
+		return ReasonNoStamp
+	}
+
+	elapsed := now.Sub(time.UnixMilli(millis))
+	switch {
+	case elapsed < c.cfg.MinFillTime:
+		return ReasonTooFast
+	case elapsed > c.cfg.MaxAge:
+		return ReasonExpired
+	}
+
+	return ""
+}
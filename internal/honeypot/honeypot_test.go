@@ -0,0 +1,86 @@
+package honeypot_test
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dreamsofcode-io/guestbook/internal/config"
+	"github.com/dreamsofcode-io/guestbook/internal/honeypot"
+)
+
+func TestCheck(t *testing.T) {
+	checker := honeypot.New([]byte("0123456789abcdef0123456789abcdef"), &config.Honeypot{
+		MinFillTime: 2 * time.Second,
+		MaxAge:      time.Hour,
+	})
+	other := honeypot.New([]byte("fedcba9876543210fedcba9876543210"), &config.Honeypot{
+		MinFillTime: 2 * time.Second,
+		MaxAge:      time.Hour,
+	})
+
+	shown := time.Now()
+	stamp := checker.Stamp(shown)
+
+	testCases := []struct {
+		Description string
+		Form        url.Values
+		Now         time.Time
+		Expected    string
+	}{
+		{
+			Description: "person",
+			Form:        url.Values{honeypot.StampField: {stamp}},
+			Now:         shown.Add(10 * time.Second),
+		},
+		{
+			Description: "honeypot filled in",
+			Form: url.Values{
+				honeypot.StampField: {stamp},
+				honeypot.Field:      {"Cheap watches"},
+			},
+			Now:      shown.Add(10 * time.Second),
+			Expected: honeypot.ReasonHoneypot,
+		},
+		{
+			Description: "too fast",
+			Form:        url.Values{honeypot.StampField: {stamp}},
+			Now:         shown.Add(500 * time.Millisecond),
+			Expected:    honeypot.ReasonTooFast,
+		},
+		{
+			Description: "expired",
+			Form:        url.Values{honeypot.StampField: {stamp}},
+			Now:         shown.Add(2 * time.Hour),
+			Expected:    honeypot.ReasonExpired,
+		},
+		{
+			Description: "missing stamp",
+			Form:        url.Values{},
+			Now:         shown.Add(10 * time.Second),
+			Expected:    honeypot.ReasonNoStamp,
+		},
+		{
+			Description: "stamp signed with another key",
+			Form:        url.Values{honeypot.StampField: {other.Stamp(shown)}},
+			Now:         shown.Add(10 * time.Second),
+			Expected:    honeypot.ReasonNoStamp,
+		},
+		{
+			Description: "backdated stamp",
+			Form: url.Values{
+				honeypot.StampField: {"1000" + stamp[len(stamp)-44:]},
+			},
+			Now:      shown.Add(10 * time.Second),
+			Expected: honeypot.ReasonNoStamp,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Description, func(t *testing.T) {
+			assert.Equal(t, tc.Expected, checker.Check(tc.Form, tc.Now))
+		})
+	}
+}
@@ -0,0 +1,76 @@
+// Package identicon draws the small symmetric images used to tell the
+// authors of entries apart without showing who they are.
+package identicon
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/dreamsofcode-io/guestbook/internal/clientip"
+)
+
+// size is the number of cells along each side. Only the left half and the
+// middle column are drawn from the hash, the right half mirrors them.
+const size = 5
+
+const background = "#1f2937"
+
+// Generator draws identicons from a keyed hash of the client's address, so
+// the same visitor always gets the same image but the image can't be used
+// to work out their address.
+type Generator struct {
+	key []byte
+}
+
+func New(key []byte) *Generator {
+	return &Generator{
+		key: key,
+	}
+}
+
+// Sum returns the keyed hash an identicon is drawn from.
+func (g *Generator) Sum(ip net.IP) []byte {
+	mac := hmac.New(sha256.New, g.key)
+	mac.Write([]byte("identicon:"))
+	mac.Write(clientip.Subnet(ip))
+
+	return mac.Sum(nil)
+}
+
+// SVG draws the identicon for a client address.
+func (g *Generator) SVG(ip net.IP) []byte {
+	return Render(g.Sum(ip))
+}
+
+// Render draws an identicon from a hash of at least four bytes. The first
+// two pick the colour and the next two which cells are filled.
+func Render(sum []byte) []byte {
+	hue := binary.BigEndian.Uint16(sum[0:2]) % 360
+	bits := binary.BigEndian.Uint16(sum[2:4])
+
+	var path strings.Builder
+
+	for y := range size {
+		for x := range (size + 1) / 2 {
+			if bits&(1<<(y*((size+1)/2)+x)) == 0 {
+				continue
+			}
+
+			fmt.Fprintf(&path, "M%d %dh1v1h-1z", x, y)
+			if mirror := size - 1 - x; mirror != x {
+				fmt.Fprintf(&path, "M%d %dh1v1h-1z", mirror, y)
+			}
+		}
+	}
+
+	return []byte(fmt.Sprintf(
+		`<svg xmlns="http://www.w3.org/2000/svg" viewBox="-1 -1 %d %d" shape-rendering="crispEdges">`+
+			`<rect x="-1" y="-1" width="%d" height="%d" fill="%s"/>`+
+			`<path fill="hsl(%d, 60%%, 55%%)" d="%s"/></svg>`,
+		size+2, size+2, size+2, size+2, background, hue, path.String(),
+	))
+}
@@ -0,0 +1,30 @@
+package identicon_test
+
+import (
+	"encoding/xml"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dreamsofcode-io/guestbook/internal/identicon"
+)
+
+func TestGenerator(t *testing.T) {
+	gen := identicon.New([]byte("secret"))
+
+	a := gen.SVG(net.ParseIP("192.0.2.1"))
+	assert.Equal(t, a, gen.SVG(net.ParseIP("192.0.2.1")))
+	assert.NotEqual(t, a, gen.SVG(net.ParseIP("192.0.2.2")))
+	assert.NotEqual(t, a, identicon.New([]byte("other")).SVG(net.ParseIP("192.0.2.1")))
+
+	assert.NoError(t, xml.Unmarshal(a, new(struct{})))
+}
+
+func TestRender(t *testing.T) {
+	// Only the top left cell is set, so it is mirrored to the top right.
+	svg := string(identicon.Render([]byte{0, 10, 0, 1}))
+
+	assert.Contains(t, svg, `fill="hsl(10, 60%, 55%)"`)
+	assert.Contains(t, svg, `d="M0 0h1v1h-1zM4 0h1v1h-1z"`)
+}
@@ -0,0 +1,202 @@
+// Package markdown renders the small subset of Markdown allowed in
+// messages: **bold**, *italics* or _italics_, `inline code` and
+// [links](https://example.com).
+//
+// Everything else, including any HTML in the message, is escaped and shown
+// as written. The only markup in the output is <strong>, <em>, <code> and
+// <a> elements with an http or https href, always properly nested.
+package markdown
+
+import (
+	"html"
+	"html/template"
+	"net/url"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxDepth bounds how deeply formatting can be nested. Deeper markers are
+// left as text.
+const maxDepth = 8
+
+// rel is set on every link, since links come from visitors.
+const rel = "nofollow ugc noopener"
+
+// Render converts a message to HTML.
+func Render(s string) template.HTML {
+	var b strings.Builder
+	render(&b, s, true, 0)
+
+	return template.HTML(b.String())
+}
+
+// escapable are the characters a backslash can stop from being treated as
+// formatting.
+const escapable = "\\`*_[]()"
+
+func render(b *strings.Builder, s string, links bool, depth int) {
+	for i := 0; i < len(s); {
+		if depth < maxDepth {
+			if n := format(b, s, i, links, depth); n > 0 {
+				i += n
+				continue
+			}
+		}
+
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == '\\' && i+1 < len(s) && strings.IndexByte(escapable, s[i+1]) >= 0 {
+			b.WriteString(html.EscapeString(s[i+1 : i+2]))
+			i += 2
+			continue
+		}
+
+		b.WriteString(html.EscapeString(string(r)))
+		i += size
+	}
+}
+
+// format renders the formatting starting at s[i], if any, and returns how
+// many bytes it used.
+func format(b *strings.Builder, s string, i int, links bool, depth int) int {
+	switch {
+	case s[i] == '`':
+		end := strings.IndexByte(s[i+1:], '`')
+		if end <= 0 {
+			return 0
+		}
+
+		b.WriteString("<code>")
+		b.WriteString(html.EscapeString(s[i+1 : i+1+end]))
+		b.WriteString("</code>")
+
+		return end + 2
+	case strings.HasPrefix(s[i:], "**"):
+		return wrap(b, s, i, "**", "strong", links, depth)
+	case s[i] == '*':
+		return wrap(b, s, i, "*", "em", links, depth)
+	case s[i] == '_' && !wordBefore(s, i):
+		return wrap(b, s, i, "_", "em", links, depth)
+	case s[i] == '[' && links:
+		return link(b, s, i, depth)
+	}
+
+	return 0
+}
+
+// wrap renders the text between a pair of delimiters inside the given
+// element. The text can't be empty or start or end with a space, and an
+// underscore only closes at the end of a word.
+func wrap(
+	b *strings.Builder, s string, i int, delim, tag string, links bool,
+	depth int,
+) int {
+	start := i + len(delim)
+
+	for j := start + 1; j+len(delim) <= len(s); j++ {
+		if s[j] == '\\' {
+			j++
+			continue
+		}
+
+		if !strings.HasPrefix(s[j:], delim) {
+			continue
+		}
+
+		// A single * must not close on half of a **, and a ** followed by
+		// another * closes on the last two so the first can close an *.
+		if delim == "*" && strings.HasPrefix(s[j:], "**") {
+			j++
+			continue
+		}
+
+		if delim == "**" && strings.HasPrefix(s[j:], "***") {
+			j++
+		}
+
+		if delim == "_" && wordAfter(s, j+1) {
+			continue
+		}
+
+		inner := s[start:j]
+		if strings.TrimSpace(inner) != inner {
+			return 0
+		}
+
+		b.WriteString("<" + tag + ">")
+		render(b, inner, links, depth+1)
+		b.WriteString("</" + tag + ">")
+
+		return j + len(delim) - i
+	}
+
+	return 0
+}
+
+// link renders [text](url) when the url is an absolute http or https URL.
+func link(b *strings.Builder, s string, i int, depth int) int {
+	closeText := strings.IndexByte(s[i:], ']')
+	if closeText <= 1 || !strings.HasPrefix(s[i+closeText:], "](") ||
+		strings.IndexByte(s[i+1:i+closeText], '[') >= 0 {
+		return 0
+	}
+
+	open := i + closeText + 2
+	closeURL := strings.IndexByte(s[open:], ')')
+	if closeURL <= 0 {
+		return 0
+	}
+
+	href, ok := safeURL(s[open : open+closeURL])
+	if !ok {
+		return 0
+	}
+
+	b.WriteString(`<a href="`)
+	b.WriteString(html.EscapeString(href))
+	b.WriteString(`" rel="` + rel + `">`)
+	render(b, s[i+1:i+closeText], false, depth+1)
+	b.WriteString("</a>")
+
+	return open + closeURL + 1 - i
+}
+
+// unsafeChars can't appear in a link, even though url.Parse allows some of
+// them in hosts.
+const unsafeChars = "\"'<>\\`"
+
+// safeURL returns the normal form of an absolute http or https URL.
+func safeURL(raw string) (string, bool) {
+	if strings.IndexFunc(raw, unicode.IsSpace) >= 0 {
+		return "", false
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", false
+	}
+
+	href := u.String()
+	if strings.ContainsAny(href, unsafeChars) {
+		return "", false
+	}
+
+	return href, true
+}
+
+// wordBefore and wordAfter report whether the character before or at i is
+// part of a word, so underscores inside names like snake_case are left
+// alone.
+func wordBefore(s string, i int) bool {
+	r, _ := utf8.DecodeLastRuneInString(s[:i])
+	return isWord(r)
+}
+
+func wordAfter(s string, i int) bool {
+	r, _ := utf8.DecodeRuneInString(s[i:])
+	return isWord(r)
+}
+
+func isWord(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
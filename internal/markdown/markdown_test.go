@@ -0,0 +1,124 @@
+package markdown_test
+
+import (
+	"html"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dreamsofcode-io/guestbook/internal/markdown"
+)
+
+func TestRender(t *testing.T) {
+	testCases := []struct {
+		Input    string
+		Expected string
+	}{
+		{Input: "hello", Expected: "hello"},
+		{Input: "**bold** and *italic*", Expected: "<strong>bold</strong> and <em>italic</em>"},
+		{Input: "_italic_ but not snake_case_name", Expected: "<em>italic</em> but not snake_case_name"},
+		{Input: "**bold *and italic***", Expected: "<strong>bold <em>and italic</em></strong>"},
+		{Input: "`<b>*code*</b>`", Expected: "<code>&lt;b&gt;*code*&lt;/b&gt;</code>"},
+		{Input: "[site](https://example.com/?a=1&b=2)", Expected: `<a href="https://example.com/?a=1&amp;b=2" rel="nofollow ugc noopener">site</a>`},
+		{Input: "[**bold** site](http://example.com)", Expected: `<a href="http://example.com" rel="nofollow ugc noopener"><strong>bold</strong> site</a>`},
+		{Input: "[x](javascript:alert(1))", Expected: "[x](javascript:alert(1))"},
+		{Input: "[x](//example.com)", Expected: "[x](//example.com)"},
+		{Input: "[[x](https://a.example)](https://b.example)", Expected: `[<a href="https://a.example" rel="nofollow ugc noopener">x</a>](https://b.example)`},
+		{Input: "<script>alert(1)</script>", Expected: "&lt;script&gt;alert(1)&lt;/script&gt;"},
+		{Input: `\*not italic\*`, Expected: "*not italic*"},
+		{Input: "* not italic *", Expected: "* not italic *"},
+		{Input: "unclosed **bold", Expected: "unclosed **bold"},
+		{Input: "it's \"quoted\"", Expected: "it&#39;s &#34;quoted&#34;"},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.Input, func(t *testing.T) {
+			assert.Equal(t, test.Expected, string(markdown.Render(test.Input)))
+		})
+	}
+}
+
+// check verifies the output only contains the allowed elements, properly
+// nested, and that every link is an http or https URL.
+func check(out string) string {
+	var stack []string
+
+	for out != "" {
+		i := strings.IndexByte(out, '<')
+		if i < 0 {
+			break
+		}
+
+		out = out[i:]
+
+		switch {
+		case strings.HasPrefix(out, "<strong>"), strings.HasPrefix(out, "<em>"),
+			strings.HasPrefix(out, "<code>"):
+			end := strings.IndexByte(out, '>')
+			stack = append(stack, out[1:end])
+			out = out[end+1:]
+		case strings.HasPrefix(out, `<a href="`):
+			end := strings.Index(out, `" rel="nofollow ugc noopener">`)
+			if end < 0 {
+				return "link without rel: " + out
+			}
+
+			href := html.UnescapeString(out[len(`<a href="`):end])
+			if strings.ContainsAny(href, `"<>`) {
+				return "unescaped href: " + href
+			}
+
+			u, err := url.Parse(href)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+				return "unsafe href: " + href
+			}
+
+			stack = append(stack, "a")
+			out = out[end+len(`" rel="nofollow ugc noopener">`):]
+		case strings.HasPrefix(out, "</"):
+			end := strings.IndexByte(out, '>')
+			if end < 0 || len(stack) == 0 || stack[len(stack)-1] != out[2:end] {
+				return "unbalanced close: " + out
+			}
+
+			stack = stack[:len(stack)-1]
+			out = out[end+1:]
+		default:
+			return "raw html: " + out
+		}
+	}
+
+	if len(stack) > 0 {
+		return "unclosed " + stack[len(stack)-1]
+	}
+
+	return ""
+}
+
+func FuzzRender(f *testing.F) {
+	for _, seed := range []string{
+		"**bold** *italic* _em_ `code`",
+		"[link](https://example.com) [bad](javascript:alert(1))",
+		"<img src=x onerror=alert(1)>",
+		`[x](https://example.com/"onmouseover="alert(1))`,
+		"[x](https://example.com/<script>)",
+		"***__*`*`*__***",
+		`\[x\](https://example.com)`,
+		"[**[x](http://a)**](http://b)",
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, s string) {
+		out := string(markdown.Render(s))
+		if msg := check(out); msg != "" {
+			t.Fatalf("render(%q) = %q: %s", s, out, msg)
+		}
+
+		if strings.Contains(strings.ToLower(out), "javascript:") && !strings.Contains(strings.ToLower(s), "javascript:") {
+			t.Fatalf("render(%q) = %q introduced a javascript: url", s, out)
+		}
+	})
+}
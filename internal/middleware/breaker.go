@@ -0,0 +1,128 @@
+package middleware
+
+import (
+	"sync"
+	"time"
+)
+
+// BreakerState is the state of a circuit breaker.
+type BreakerState int
+
+const (
+	// BreakerClosed lets every call through.
+	BreakerClosed BreakerState = iota
+	// BreakerOpen rejects calls until the cooldown has passed.
+	BreakerOpen
+	// BreakerHalfOpen lets a single probe through to test for recovery.
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half_open"
+	default:
+		return "closed"
+	}
+}
+
+// Breaker is a circuit breaker that opens after a number of consecutive
+// failures, so calls to a dead dependency fail fast instead of waiting on
+// timeouts. After the cooldown a single probe is let through, which closes
+// the breaker again if it succeeds.
+type Breaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// NewBreaker creates a breaker that opens after threshold consecutive
+// failures and stays open for cooldown.
+func NewBreaker(threshold int, cooldown time.Duration) *Breaker {
+	return &Breaker{
+		threshold: max(threshold, 1),
+		cooldown:  cooldown,
+	}
+}
+
+// Allow reports whether a call should be attempted. Every allowed call must
+// be followed by Success, Failure or Cancel.
+func (b *Breaker) Allow(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if now.Sub(b.openedAt) < b.cooldown {
+			return false
+		}
+
+		b.state = BreakerHalfOpen
+		b.probing = true
+
+		return true
+	case BreakerHalfOpen:
+		if b.probing {
+			return false
+		}
+
+		b.probing = true
+
+		return true
+	default:
+		return true
+	}
+}
+
+// Success records a successful call and returns the previous state.
+func (b *Breaker) Success() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	prev := b.state
+	b.state = BreakerClosed
+	b.failures = 0
+	b.probing = false
+
+	return prev
+}
+
+// Failure records a failed call and returns the new state.
+func (b *Breaker) Failure(now time.Time) BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.probing = false
+
+	if b.state == BreakerHalfOpen || b.failures >= b.threshold {
+		b.state = BreakerOpen
+		b.openedAt = now
+	}
+
+	return b.state
+}
+
+// Cancel records that an allowed call ended without telling anything about
+// the dependency's health, such as when the caller went away.
+func (b *Breaker) Cancel() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+}
+
+// State returns the current state of the breaker.
+func (b *Breaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state
+}
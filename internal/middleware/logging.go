@@ -1,15 +1,20 @@
 package middleware
 
 import (
+	"bufio"
 	"html/template"
 	"log/slog"
+	"net"
 	"net/http"
 	"time"
+
+	"github.com/dreamsofcode-io/guestbook/internal/clientip"
 )
 
 type wrappedWriter struct {
 	http.ResponseWriter
 	statusCode int
+	written    bool
 }
 
 func (w *wrappedWriter) WriteHeader(statusCode int) {
@@ -17,11 +22,38 @@ func (w *wrappedWriter) WriteHeader(statusCode int) {
 	w.statusCode = statusCode
 }
 
+func (w *wrappedWriter) Write(b []byte) (int, error) {
+	w.written = true
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush lets streaming handlers, such as server-sent events, flush through
+// the wrapper.
+func (w *wrappedWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack lets websocket handlers take over the connection through the
+// wrapper.
+func (w *wrappedWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(w.ResponseWriter).Hijack()
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (w *wrappedWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
 type errorPage struct {
 	StatusCode    int
 	StatusMessage string
+	ErrorMessage  string
 }
 
+// HandleBadCode renders the error page for any response with an error status
+// code, unless the handler has already written a body of its own.
 func HandleBadCode(tmpl *template.Template, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		wrapped := &wrappedWriter{
@@ -31,7 +63,7 @@ func HandleBadCode(tmpl *template.Template, next http.Handler) http.Handler {
 
 		next.ServeHTTP(wrapped, r)
 
-		if wrapped.statusCode >= 400 {
+		if wrapped.statusCode >= 400 && !wrapped.written {
 			tmpl.ExecuteTemplate(w, "error.html", errorPage{
 				StatusCode:    wrapped.statusCode,
 				StatusMessage: http.StatusText(wrapped.statusCode),
@@ -54,6 +86,7 @@ func Logging(logger *slog.Logger, next http.Handler) http.Handler {
 		logger.Info(
 			"handled request",
 			slog.Int("statusCode", wrapped.statusCode),
+			slog.String("clientIP", clientip.FromRequest(r).String()),
 			slog.String("remoteAddr", r.RemoteAddr),
 			slog.String("xffHeader", r.Header.Get("X-Forwarded-For")),
 			slog.String("method", r.Method),
@@ -5,3 +5,15 @@ import "net/http"
 // Middleware represents the type signature of a middleware
 // function.
 type Middleware func(http.Handler) http.Handler
+
+// Chain composes the given middleware into one. The first middleware is
+// the outermost, so it sees the request first.
+func Chain(ms ...Middleware) Middleware {
+	return func(next http.Handler) http.Handler {
+		for i := len(ms) - 1; i >= 0; i-- {
+			next = ms[i](next)
+		}
+
+		return next
+	}
+}
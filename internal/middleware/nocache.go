@@ -6,7 +6,7 @@ import (
 
 func NoCache(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-store")
 		next.ServeHTTP(w, r)
-		r.Header.Add("Cache-Control", "max-age=0")
 	})
 }
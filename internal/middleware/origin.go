@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"net/http"
+	"net/url"
+)
+
+// SafeMethod reports whether requests with the method don't change state.
+func SafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+
+	return false
+}
+
+// CrossOrigin reports whether the browser says a request came from another
+// site, through Sec-Fetch-Site or, in older browsers, Origin.
+func CrossOrigin(r *http.Request) bool {
+	switch r.Header.Get("Sec-Fetch-Site") {
+	case "same-origin", "none":
+		return false
+	case "":
+	default:
+		return true
+	}
+
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return false
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return true
+	}
+
+	return u.Host != r.Host
+}
@@ -3,83 +3,95 @@ package middleware
 import (
 	"math"
 	"net/http"
-	"regexp"
 	"strconv"
-	"strings"
 	"time"
-
-	"github.com/redis/go-redis/v9"
 )
 
 type RateLimiter struct {
-	Period  time.Duration
-	MaxRate int64
-	Store   *redis.Client
+	// Name keeps the counts of limiters sharing a store apart.
+	Name  string
+	Limit Limit
+	Store RateLimitStore
+	// Key identifies who a request is counted against. It defaults to
+	// KeyByIP.
+	Key KeyFunc
+	// FailClosed rejects requests with 503 when the store can't make a
+	// decision, rather than letting them through.
+	FailClosed bool
 }
 
-var re = regexp.MustCompile(`\s?,\s?`)
+func (rl *RateLimiter) key(r *http.Request) string {
+	key := KeyByIP
+	if rl.Key != nil {
+		key = rl.Key
+	}
 
-func (rl *RateLimiter) writeRateLimitHeaders(
-	w http.ResponseWriter,
-	used int64,
-	expireTime time.Duration,
-) {
-	limit := rl.MaxRate
-	remaining := int64(math.Max(float64(limit-used), 0))
-	reset := int64(math.Ceil(expireTime.Seconds()))
+	if rl.Name == "" {
+		return "ratelimit:" + key(r)
+	}
 
-	w.Header().Add("X-RateLimit-Limit", strconv.FormatInt(limit, 10))
-	w.Header().Add("X-RateLimit-Remaining", strconv.FormatInt(remaining, 10))
-	w.Header().Add("X-RateLimit-Reset", strconv.FormatInt(reset, 10))
+	return "ratelimit:" + rl.Name + ":" + key(r)
 }
 
-func (rl *RateLimiter) Middleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Obtain the clientIP from the XFF header
-		clientIP := re.Split(r.Header.Get("X-Forwarded-For"), -1)[0]
-
-		// If the xff header is empty, obtain the IP from the remoteAddr
-		if clientIP == "" {
-			parts := strings.Split(r.RemoteAddr, ":")
-			clientIP = strings.Join(parts[0:len(parts)-1], ":")
-		}
-
-		// Get the current time to use for the event
-		now := time.Now()
-
-		// Add the current event to the store
-		rl.Store.ZAdd(r.Context(), clientIP, redis.Z{
-			Member: now.UnixMicro(),
-			Score:  float64(now.UnixMicro()),
-		})
-
-		// Calculate the cutoff
-		cutoff := now.Add(rl.Period * -1).UnixMicro()
-
-		// Remove all events that are before the cutoff
-		rl.Store.ZRemRangeByScore(r.Context(), clientIP, "-inf", strconv.FormatInt(cutoff, 10))
+// seconds rounds a duration up to whole seconds, as used by the headers.
+func seconds(d time.Duration) int64 {
+	return int64(math.Ceil(max(d, 0).Seconds()))
+}
 
-		// Pull the remaining events from the sorted set
-		events, _ := rl.Store.ZRange(r.Context(), clientIP, 0, -1).Result()
+// writeRateLimitHeaders writes both the legacy X-RateLimit-* headers and
+// the RateLimit-* headers from the IETF httpapi ratelimit draft.
+func (rl *RateLimiter) writeRateLimitHeaders(w http.ResponseWriter, res Result) {
+	limit := strconv.FormatInt(res.Limit, 10)
+	remaining := strconv.FormatInt(max(res.Remaining, 0), 10)
+	reset := strconv.FormatInt(seconds(res.ResetAfter), 10)
+
+	w.Header().Add("X-RateLimit-Limit", limit)
+	w.Header().Add("X-RateLimit-Remaining", remaining)
+	w.Header().Add("X-RateLimit-Reset", reset)
+
+	w.Header().Add("RateLimit-Limit", limit)
+	w.Header().Add("RateLimit-Remaining", remaining)
+	w.Header().Add("RateLimit-Reset", reset)
+	w.Header().Add("RateLimit-Policy", limit+";w="+strconv.FormatInt(seconds(rl.Limit.Period), 10))
+
+	if !res.Allowed {
+		w.Header().Add("Retry-After", strconv.FormatInt(max(seconds(res.RetryAfter), 1), 10))
+	}
+}
 
-		// Get the earliest event time
-		earliestMicro, _ := strconv.ParseInt(events[0], 10, 64)
-		earliest := time.UnixMicro(earliestMicro)
+// Allow counts the request against the limit. It is used directly by
+// handlers that accept more than one action per request, such as messages on
+// a websocket.
+func (rl *RateLimiter) Allow(r *http.Request) (Result, error) {
+	return rl.Store.Allow(r.Context(), rl.key(r), rl.Limit, time.Now())
+}
 
-		// Calculate how long until it resets and how many events have occurred
-		resets := rl.Period - time.Since(earliest)
-		eventCount := int64(len(events))
+func (rl *RateLimiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Ask the store whether the request is within the limit
+		res, err := rl.Allow(r)
+		if err != nil {
+			if rl.FailClosed && r.Context().Err() == nil {
+				w.Header().Set("Retry-After", "1")
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+
+			// Don't take the site down with the store
+			next.ServeHTTP(w, r)
+			return
+		}
 
 		// write the rate limit headers
-		rl.writeRateLimitHeaders(w, eventCount, resets)
+		rl.writeRateLimitHeaders(w, res)
 
-		// Check if client has exceeded the max rate
-		if eventCount > rl.MaxRate {
+		// Check if client has exceeded the limit
+		if !res.Allowed {
 			w.WriteHeader(http.StatusTooManyRequests)
 			return
 		}
 
-		// Call the next handler if rate is not exceeded
+		// Call the next handler if the limit is not exceeded
 		next.ServeHTTP(w, r)
 	})
 }
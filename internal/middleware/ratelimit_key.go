@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/dreamsofcode-io/guestbook/internal/clientip"
+)
+
+// KeyFunc returns the identity a request is counted against.
+type KeyFunc func(r *http.Request) string
+
+// hashKey keeps secrets such as API keys and session tokens out of the
+// store's keys.
+func hashKey(prefix, secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return prefix + ":" + hex.EncodeToString(sum[:16])
+}
+
+// KeyByIP counts requests against the client IP resolved from the trusted
+// proxy chain. IPv6 clients are counted by their /64 network, as they can
+// pick any address within it.
+func KeyByIP(r *http.Request) string {
+	return "ip:" + clientip.Subnet(clientip.IP(clientip.FromRequest(r))).String()
+}
+
+// Validator reports whether a credential, such as an API key or a session
+// token, is one the application handed out and still accepts.
+type Validator func(ctx context.Context, credential string) bool
+
+// APIKey returns the API key sent in the Authorization bearer token or the
+// X-API-Key header, or an empty string when there is none. The key hasn't
+// been checked.
+func APIKey(r *http.Request) string {
+	key := r.Header.Get("X-API-Key")
+
+	if auth := r.Header.Get("Authorization"); key == "" && len(auth) > 7 &&
+		strings.EqualFold(auth[:7], "bearer ") {
+		key = strings.TrimSpace(auth[7:])
+	}
+
+	return key
+}
+
+// KeyByAPIKey counts requests against the API key they carry. Only keys
+// the validator accepts get their own count, anyone else is counted
+// against their client IP, so making up a key for every request doesn't
+// get around the limit.
+func KeyByAPIKey(valid Validator) KeyFunc {
+	return func(r *http.Request) string {
+		key := APIKey(r)
+		if key == "" || !valid(r.Context(), key) {
+			return KeyByIP(r)
+		}
+
+		return hashKey("key", key)
+	}
+}
+
+// KeyBySession counts requests against the session held in the named
+// cookie. Only sessions the validator accepts get their own count, anyone
+// else is counted against their client IP, so making up a new cookie for
+// every request doesn't get around the limit.
+func KeyBySession(cookie string, valid Validator) KeyFunc {
+	return func(r *http.Request) string {
+		c, err := r.Cookie(cookie)
+		if err != nil || c.Value == "" || !valid(r.Context(), c.Value) {
+			return KeyByIP(r)
+		}
+
+		return hashKey("session", c.Value)
+	}
+}
@@ -0,0 +1,271 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"hash/maphash"
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	defaultShards        = 32
+	defaultEvictInterval = time.Minute
+)
+
+// memoryEntry holds the state of a single key. Only the fields used by the
+// key's algorithm are set.
+type memoryEntry struct {
+	events  []time.Time
+	start   time.Time
+	count   int64
+	tokens  float64
+	last    time.Time
+	tat     time.Time
+	expires time.Time
+}
+
+type memoryShard struct {
+	mu      sync.Mutex
+	entries map[string]*memoryEntry
+}
+
+// MemoryStore applies rate limits in process. Keys are spread over several
+// independently locked shards to reduce contention, and idle keys are
+// evicted in the background. Limits are not shared between replicas.
+type MemoryStore struct {
+	seed   maphash.Seed
+	shards []*memoryShard
+	done   chan struct{}
+	once   sync.Once
+}
+
+// NewMemoryStore creates a store and starts evicting idle keys every
+// interval. Close must be called to stop the eviction.
+func NewMemoryStore(shards int, interval time.Duration) *MemoryStore {
+	if shards <= 0 {
+		shards = defaultShards
+	}
+
+	if interval <= 0 {
+		interval = defaultEvictInterval
+	}
+
+	s := &MemoryStore{
+		seed:   maphash.MakeSeed(),
+		shards: make([]*memoryShard, shards),
+		done:   make(chan struct{}),
+	}
+
+	for i := range s.shards {
+		s.shards[i] = &memoryShard{
+			entries: map[string]*memoryEntry{},
+		}
+	}
+
+	go s.evictLoop(interval)
+
+	return s
+}
+
+func (s *MemoryStore) shard(key string) *memoryShard {
+	return s.shards[maphash.String(s.seed, key)%uint64(len(s.shards))]
+}
+
+func (s *MemoryStore) Allow(
+	ctx context.Context, key string, limit Limit, now time.Time,
+) (Result, error) {
+	shard := s.shard(key)
+
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	entry, ok := shard.entries[key]
+	if !ok {
+		entry = &memoryEntry{}
+		shard.entries[key] = entry
+	}
+
+	var res Result
+
+	switch limit.Algorithm {
+	case SlidingWindowLog:
+		res = entry.slidingLog(limit, now)
+	case FixedWindow:
+		res = entry.fixedWindow(limit, now)
+	case TokenBucket:
+		res = entry.tokenBucket(limit, now)
+	case GCRA:
+		res = entry.gcra(limit, now)
+	default:
+		return Result{}, fmt.Errorf("unknown rate limit algorithm %q", limit.Algorithm)
+	}
+
+	res.Limit = limit.max()
+
+	return res, nil
+}
+
+func (e *memoryEntry) slidingLog(limit Limit, now time.Time) Result {
+	e.events = trimEvents(e.events, now.Add(-limit.Period))
+
+	allowed := int64(len(e.events)) < limit.Rate
+	if allowed {
+		e.events = append(e.events, now)
+	}
+
+	oldest, newest := now, now
+	if len(e.events) > 0 {
+		oldest, newest = e.events[0], e.events[len(e.events)-1]
+	}
+
+	e.expires = newest.Add(limit.Period)
+
+	res := Result{
+		Allowed:    allowed,
+		Remaining:  limit.Rate - int64(len(e.events)),
+		ResetAfter: oldest.Add(limit.Period).Sub(now),
+	}
+
+	if !allowed {
+		res.RetryAfter = res.ResetAfter
+	}
+
+	return res
+}
+
+// trimEvents drops the events at or before the cutoff. Events are appended
+// in time order so the oldest are always at the front.
+func trimEvents(events []time.Time, cutoff time.Time) []time.Time {
+	i := 0
+	for i < len(events) && !events[i].After(cutoff) {
+		i++
+	}
+
+	return events[i:]
+}
+
+func (e *memoryEntry) fixedWindow(limit Limit, now time.Time) Result {
+	// Windows are aligned to the unix epoch to match the redis store.
+	micro := now.UnixMicro()
+	start := time.UnixMicro(micro - micro%limit.Period.Microseconds())
+
+	if !e.start.Equal(start) {
+		e.start = start
+		e.count = 0
+	}
+
+	allowed := e.count < limit.Rate
+	if allowed {
+		e.count++
+	}
+
+	e.expires = start.Add(limit.Period)
+
+	res := Result{
+		Allowed:    allowed,
+		Remaining:  limit.Rate - e.count,
+		ResetAfter: e.expires.Sub(now),
+	}
+
+	if !allowed {
+		res.RetryAfter = res.ResetAfter
+	}
+
+	return res
+}
+
+func (e *memoryEntry) tokenBucket(limit Limit, now time.Time) Result {
+	burst := float64(limit.burst())
+	emission := float64(limit.emission())
+
+	if e.last.IsZero() {
+		e.tokens = burst
+		e.last = now
+	}
+
+	elapsed := max(float64(now.Sub(e.last)), 0)
+	e.tokens = math.Min(burst, e.tokens+elapsed/emission)
+	e.last = now
+
+	res := Result{}
+	if e.tokens >= 1 {
+		e.tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = time.Duration(math.Ceil((1 - e.tokens) * emission))
+	}
+
+	res.Remaining = int64(math.Floor(e.tokens))
+	res.ResetAfter = time.Duration(math.Ceil((burst - e.tokens) * emission))
+	e.expires = now.Add(res.ResetAfter)
+
+	return res
+}
+
+func (e *memoryEntry) gcra(limit Limit, now time.Time) Result {
+	emission := limit.emission()
+	tolerance := emission * time.Duration(limit.burst())
+
+	tat := e.tat
+	if tat.Before(now) {
+		tat = now
+	}
+
+	newTat := tat.Add(emission)
+	allowAt := newTat.Add(-tolerance)
+
+	if now.Before(allowAt) {
+		return Result{
+			Allowed:    false,
+			Remaining:  0,
+			RetryAfter: allowAt.Sub(now),
+			ResetAfter: tat.Sub(now),
+		}
+	}
+
+	e.tat = newTat
+	e.expires = newTat
+
+	return Result{
+		Allowed:    true,
+		Remaining:  int64(now.Sub(allowAt) / emission),
+		ResetAfter: newTat.Sub(now),
+	}
+}
+
+func (s *MemoryStore) evictLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case now := <-ticker.C:
+			s.evict(now)
+		}
+	}
+}
+
+func (s *MemoryStore) evict(now time.Time) {
+	for _, shard := range s.shards {
+		shard.mu.Lock()
+		for key, entry := range shard.entries {
+			if !now.Before(entry.expires) {
+				delete(shard.entries, key)
+			}
+		}
+		shard.mu.Unlock()
+	}
+}
+
+// Close stops the background eviction.
+func (s *MemoryStore) Close() error {
+	s.once.Do(func() {
+		close(s.done)
+	})
+
+	return nil
+}
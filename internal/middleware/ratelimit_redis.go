@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+//go:embed scripts/*.lua
+var scripts embed.FS
+
+func loadScript(name string) *redis.Script {
+	src, err := scripts.ReadFile("scripts/" + name + ".lua")
+	if err != nil {
+		panic(fmt.Sprintf("missing rate limit script %s: %s", name, err))
+	}
+
+	return redis.NewScript(string(src))
+}
+
+var redisScripts = map[Algorithm]*redis.Script{
+	SlidingWindowLog: loadScript("sliding_log"),
+	FixedWindow:      loadScript("fixed_window"),
+	TokenBucket:      loadScript("token_bucket"),
+	GCRA:             loadScript("gcra"),
+}
+
+// RedisStore applies rate limits with lua scripts, so each decision is a
+// single atomic round trip no matter how many replicas share the store.
+// Times are passed in as microseconds.
+type RedisStore struct {
+	Client *redis.Client
+}
+
+func NewRedisStore(client *redis.Client) *RedisStore {
+	return &RedisStore{
+		Client: client,
+	}
+}
+
+func (s *RedisStore) Allow(
+	ctx context.Context, key string, limit Limit, now time.Time,
+) (Result, error) {
+	script, ok := redisScripts[limit.Algorithm]
+	if !ok {
+		return Result{}, fmt.Errorf("unknown rate limit algorithm %q", limit.Algorithm)
+	}
+
+	args := []any{
+		now.UnixMicro(),
+		limit.Period.Microseconds(),
+		limit.Rate,
+	}
+
+	switch limit.Algorithm {
+	case SlidingWindowLog:
+		// The random suffix keeps requests in the same microsecond from
+		// replacing each other in the log.
+		args = append(args, fmt.Sprintf("%d-%x", now.UnixMicro(), rand.Uint64()))
+	case TokenBucket, GCRA:
+		args = append(args, limit.burst())
+	}
+
+	values, err := script.Run(ctx, s.Client, []string{key}, args...).Int64Slice()
+	if err != nil {
+		return Result{}, fmt.Errorf("run %s script: %w", limit.Algorithm, err)
+	}
+
+	if len(values) != 4 {
+		return Result{}, fmt.Errorf("unexpected %s script result: %v", limit.Algorithm, values)
+	}
+
+	return Result{
+		Allowed:    values[0] == 1,
+		Limit:      limit.max(),
+		Remaining:  max(values[1], 0),
+		RetryAfter: time.Duration(values[2]) * time.Microsecond,
+		ResetAfter: time.Duration(values[3]) * time.Microsecond,
+	}, nil
+}
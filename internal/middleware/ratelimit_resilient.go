@@ -0,0 +1,143 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+// ErrStoreUnavailable is returned when the primary store can't be used and
+// there is no fallback.
+var ErrStoreUnavailable = errors.New("rate limit store unavailable")
+
+// ResilientStore wraps a remote store, such as redis, with a timeout and a
+// circuit breaker. While the primary store is failing, decisions are made by
+// the fallback store if there is one, or ErrStoreUnavailable is returned so
+// the limiter can fail open or closed.
+type ResilientStore struct {
+	logger   *slog.Logger
+	primary  RateLimitStore
+	fallback RateLimitStore
+	timeout  time.Duration
+	breaker  *Breaker
+
+	failures      atomic.Uint64
+	fallbacks     atomic.Uint64
+	shortCircuits atomic.Uint64
+	degradedSince atomic.Int64
+}
+
+// NewResilientStore creates a store that calls primary with the given
+// timeout, and opens the breaker when it keeps failing. The fallback may be
+// nil.
+func NewResilientStore(
+	logger *slog.Logger, primary, fallback RateLimitStore, timeout time.Duration, breaker *Breaker,
+) *ResilientStore {
+	return &ResilientStore{
+		logger:   logger,
+		primary:  primary,
+		fallback: fallback,
+		timeout:  timeout,
+		breaker:  breaker,
+	}
+}
+
+func (s *ResilientStore) Allow(
+	ctx context.Context, key string, limit Limit, now time.Time,
+) (Result, error) {
+	if !s.breaker.Allow(time.Now()) {
+		s.shortCircuits.Add(1)
+		return s.degraded(ctx, key, limit, now, ErrStoreUnavailable)
+	}
+
+	callCtx := ctx
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		callCtx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+
+	res, err := s.primary.Allow(callCtx, key, limit, now)
+	if err != nil {
+		// The client going away says nothing about the store's health
+		if ctx.Err() != nil {
+			s.breaker.Cancel()
+			return Result{}, ctx.Err()
+		}
+
+		s.failures.Add(1)
+
+		if s.breaker.Failure(time.Now()) == BreakerOpen && s.degradedSince.CompareAndSwap(0, time.Now().UnixNano()) {
+			s.logger.Warn("rate limit store unavailable, entering degraded mode",
+				slog.Any("error", err),
+				slog.Bool("fallback", s.fallback != nil),
+			)
+		}
+
+		return s.degraded(ctx, key, limit, now, err)
+	}
+
+	if s.breaker.Success() != BreakerClosed {
+		since := s.degradedSince.Swap(0)
+		s.logger.Info("rate limit store recovered",
+			slog.Duration("degraded", time.Since(time.Unix(0, since))),
+		)
+	}
+
+	return res, nil
+}
+
+// degraded decides a request without the primary store.
+func (s *ResilientStore) degraded(
+	ctx context.Context, key string, limit Limit, now time.Time, cause error,
+) (Result, error) {
+	if s.fallback == nil {
+		return Result{}, errors.Join(ErrStoreUnavailable, cause)
+	}
+
+	s.fallbacks.Add(1)
+
+	return s.fallback.Allow(ctx, key, limit, now)
+}
+
+// ResilientStoreStats describes the health of a ResilientStore.
+type ResilientStoreStats struct {
+	State         string     `json:"state"`
+	Degraded      bool       `json:"degraded"`
+	DegradedSince *time.Time `json:"degraded_since,omitempty"`
+	Failures      uint64     `json:"failures"`
+	Fallbacks     uint64     `json:"fallbacks"`
+	ShortCircuits uint64     `json:"short_circuits"`
+}
+
+// Stats returns counters for the store since it was created.
+func (s *ResilientStore) Stats() ResilientStoreStats {
+	state := s.breaker.State()
+
+	stats := ResilientStoreStats{
+		State:         state.String(),
+		Degraded:      state != BreakerClosed,
+		Failures:      s.failures.Load(),
+		Fallbacks:     s.fallbacks.Load(),
+		ShortCircuits: s.shortCircuits.Load(),
+	}
+
+	if since := s.degradedSince.Load(); since != 0 {
+		t := time.Unix(0, since)
+		stats.DegradedSince = &t
+	}
+
+	return stats
+}
+
+// Close closes the fallback store if it needs closing.
+func (s *ResilientStore) Close() error {
+	if closer, ok := s.fallback.(io.Closer); ok {
+		return closer.Close()
+	}
+
+	return nil
+}
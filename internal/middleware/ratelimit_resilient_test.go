@@ -0,0 +1,152 @@
+package middleware_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dreamsofcode-io/guestbook/internal/middleware"
+)
+
+// failingStore fails every call until healthy is set.
+type failingStore struct {
+	healthy bool
+	calls   int
+}
+
+func (s *failingStore) Allow(
+	ctx context.Context, key string, limit middleware.Limit, now time.Time,
+) (middleware.Result, error) {
+	s.calls++
+
+	if !s.healthy {
+		return middleware.Result{}, errors.New("connection refused")
+	}
+
+	return middleware.Result{Allowed: true, Limit: limit.Rate, Remaining: limit.Rate - 1}, nil
+}
+
+func TestBreaker(t *testing.T) {
+	now := time.Now()
+	breaker := middleware.NewBreaker(2, time.Second)
+
+	assert.True(t, breaker.Allow(now))
+	assert.Equal(t, middleware.BreakerClosed, breaker.Failure(now))
+	assert.Equal(t, middleware.BreakerOpen, breaker.Failure(now))
+	assert.False(t, breaker.Allow(now.Add(500*time.Millisecond)))
+
+	// Only a single probe is let through after the cooldown
+	assert.True(t, breaker.Allow(now.Add(time.Second)))
+	assert.False(t, breaker.Allow(now.Add(time.Second)))
+
+	assert.Equal(t, middleware.BreakerOpen, breaker.Failure(now.Add(time.Second)))
+	assert.False(t, breaker.Allow(now.Add(1500*time.Millisecond)))
+
+	assert.True(t, breaker.Allow(now.Add(2*time.Second)))
+	assert.Equal(t, middleware.BreakerHalfOpen, breaker.Success())
+	assert.Equal(t, middleware.BreakerClosed, breaker.State())
+}
+
+func TestResilientStore(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	limit := middleware.Limit{Algorithm: middleware.FixedWindow, Rate: 1, Period: time.Minute}
+	ctx := context.Background()
+
+	t.Run("fallback", func(t *testing.T) {
+		primary := &failingStore{}
+		fallback := middleware.NewMemoryStore(1, time.Hour)
+		defer fallback.Close()
+
+		store := middleware.NewResilientStore(
+			logger, primary, fallback, 0, middleware.NewBreaker(1, time.Hour),
+		)
+
+		res, err := store.Allow(ctx, "key", limit, time.Now())
+		require.NoError(t, err)
+		assert.True(t, res.Allowed)
+
+		// The breaker is open, so the fallback limits without calling redis
+		res, err = store.Allow(ctx, "key", limit, time.Now())
+		require.NoError(t, err)
+		assert.False(t, res.Allowed)
+		assert.Equal(t, 1, primary.calls)
+
+		stats := store.Stats()
+		assert.True(t, stats.Degraded)
+		assert.NotNil(t, stats.DegradedSince)
+		assert.Equal(t, uint64(1), stats.Failures)
+		assert.Equal(t, uint64(2), stats.Fallbacks)
+		assert.Equal(t, uint64(1), stats.ShortCircuits)
+	})
+
+	t.Run("no fallback", func(t *testing.T) {
+		store := middleware.NewResilientStore(
+			logger, &failingStore{}, nil, 0, middleware.NewBreaker(1, time.Hour),
+		)
+
+		_, err := store.Allow(ctx, "key", limit, time.Now())
+		assert.ErrorIs(t, err, middleware.ErrStoreUnavailable)
+
+		_, err = store.Allow(ctx, "key", limit, time.Now())
+		assert.ErrorIs(t, err, middleware.ErrStoreUnavailable)
+	})
+
+	t.Run("recovers", func(t *testing.T) {
+		primary := &failingStore{}
+		store := middleware.NewResilientStore(
+			logger, primary, nil, 0, middleware.NewBreaker(1, time.Millisecond),
+		)
+
+		_, err := store.Allow(ctx, "key", limit, time.Now())
+		assert.Error(t, err)
+
+		primary.healthy = true
+		time.Sleep(2 * time.Millisecond)
+
+		res, err := store.Allow(ctx, "key", limit, time.Now())
+		require.NoError(t, err)
+		assert.True(t, res.Allowed)
+
+		stats := store.Stats()
+		assert.False(t, stats.Degraded)
+		assert.Nil(t, stats.DegradedSince)
+	})
+}
+
+func TestRateLimiterFailure(t *testing.T) {
+	limit := middleware.Limit{Algorithm: middleware.FixedWindow, Rate: 1, Period: time.Minute}
+
+	for _, tc := range []struct {
+		Description string
+		FailClosed  bool
+		Expected    int
+	}{
+		{Description: "fail open", Expected: http.StatusOK},
+		{Description: "fail closed", FailClosed: true, Expected: http.StatusServiceUnavailable},
+	} {
+		t.Run(tc.Description, func(t *testing.T) {
+			limiter := &middleware.RateLimiter{
+				Limit:      limit,
+				Store:      &failingStore{},
+				FailClosed: tc.FailClosed,
+			}
+
+			handler := limiter.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			assert.Equal(t, tc.Expected, w.Code)
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Algorithm is the strategy used to decide whether a request is allowed.
+type Algorithm string
+
+const (
+	// SlidingWindowLog allows Rate requests in any Period long window by
+	// keeping a log of every allowed request.
+	SlidingWindowLog Algorithm = "sliding_log"
+	// FixedWindow allows Rate requests in each Period, with the windows
+	// aligned to the clock.
+	FixedWindow Algorithm = "fixed_window"
+	// TokenBucket refills Rate tokens every Period into a bucket holding
+	// up to Burst tokens, every request takes one.
+	TokenBucket Algorithm = "token_bucket"
+	// GCRA is the generic cell rate algorithm. It spaces requests evenly
+	// at Rate per Period while tolerating bursts of up to Burst.
+	GCRA Algorithm = "gcra"
+)
+
+// ParseAlgorithm converts the name of an algorithm into an Algorithm.
+func ParseAlgorithm(s string) (Algorithm, error) {
+	switch a := Algorithm(s); a {
+	case SlidingWindowLog, FixedWindow, TokenBucket, GCRA:
+		return a, nil
+	default:
+		return "", fmt.Errorf("unknown rate limit algorithm %q", s)
+	}
+}
+
+// Limit describes how many requests are allowed and how they are counted.
+type Limit struct {
+	Algorithm Algorithm
+	Rate      int64
+	Period    time.Duration
+	// Burst is the most requests allowed at once by the token bucket and
+	// GCRA algorithms. It defaults to Rate.
+	Burst int64
+}
+
+func (l Limit) burst() int64 {
+	if l.Burst > 0 {
+		return l.Burst
+	}
+
+	return l.Rate
+}
+
+// max is the number of requests reported as the limit in headers.
+func (l Limit) max() int64 {
+	switch l.Algorithm {
+	case TokenBucket, GCRA:
+		return l.burst()
+	default:
+		return l.Rate
+	}
+}
+
+// emission is the time it takes to earn back a single request.
+func (l Limit) emission() time.Duration {
+	return l.Period / time.Duration(l.Rate)
+}
+
+// Result is the outcome of asking a store to allow a request.
+type Result struct {
+	Allowed   bool
+	Limit     int64
+	Remaining int64
+	// ResetAfter is how long until the limit has fully recovered.
+	ResetAfter time.Duration
+	// RetryAfter is how long until a denied request would be allowed.
+	RetryAfter time.Duration
+}
+
+// RateLimitStore applies rate limits to keys. Each call must be atomic, so
+// concurrent requests for the same key can't both take the last request.
+type RateLimitStore interface {
+	// Allow decides whether a request for the key at now is within the
+	// limit, and counts it if it is.
+	Allow(ctx context.Context, key string, limit Limit, now time.Time) (Result, error)
+}
@@ -0,0 +1,131 @@
+package middleware_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dreamsofcode-io/guestbook/internal/middleware"
+)
+
+var algorithms = []middleware.Algorithm{
+	middleware.SlidingWindowLog,
+	middleware.FixedWindow,
+	middleware.TokenBucket,
+	middleware.GCRA,
+}
+
+// testRateLimitStore is the behaviour every RateLimitStore must have, for
+// every algorithm.
+func testRateLimitStore(t *testing.T, store middleware.RateLimitStore) {
+	ctx := context.Background()
+
+	// Keys are unique per run so shared stores don't leak between runs.
+	key := func(name string) string {
+		return "test:" + uuid.NewString() + ":" + name
+	}
+
+	// Start at the beginning of a fixed window so every algorithm sees
+	// the same requests in the same window.
+	start := time.Now().Truncate(time.Minute).Add(time.Minute)
+
+	for _, algorithm := range algorithms {
+		limit := middleware.Limit{
+			Algorithm: algorithm,
+			Rate:      3,
+			Period:    time.Minute,
+		}
+
+		t.Run(string(algorithm), func(t *testing.T) {
+			t.Run("allows up to the limit", func(t *testing.T) {
+				k := key("limit")
+
+				for i := int64(0); i < limit.Rate; i++ {
+					res, err := store.Allow(ctx, k, limit, start)
+					assert.NoError(t, err)
+					assert.True(t, res.Allowed)
+					assert.Equal(t, limit.Rate, res.Limit)
+					assert.Equal(t, limit.Rate-i-1, res.Remaining)
+					assert.Positive(t, res.ResetAfter)
+				}
+
+				res, err := store.Allow(ctx, k, limit, start)
+				assert.NoError(t, err)
+				assert.False(t, res.Allowed)
+				assert.Equal(t, int64(0), res.Remaining)
+				assert.Positive(t, res.RetryAfter)
+				assert.LessOrEqual(t, res.RetryAfter, limit.Period)
+			})
+
+			t.Run("recovers after the period", func(t *testing.T) {
+				k := key("recover")
+
+				for i := int64(0); i <= limit.Rate; i++ {
+					store.Allow(ctx, k, limit, start)
+				}
+
+				res, err := store.Allow(ctx, k, limit, start.Add(limit.Period+time.Second))
+				assert.NoError(t, err)
+				assert.True(t, res.Allowed)
+			})
+
+			t.Run("retry after is honoured", func(t *testing.T) {
+				k := key("retry")
+
+				var res middleware.Result
+				for i := int64(0); i <= limit.Rate; i++ {
+					res, _ = store.Allow(ctx, k, limit, start)
+				}
+
+				assert.False(t, res.Allowed)
+
+				res, err := store.Allow(ctx, k, limit, start.Add(res.RetryAfter+time.Millisecond))
+				assert.NoError(t, err)
+				assert.True(t, res.Allowed)
+			})
+
+			t.Run("keys are independent", func(t *testing.T) {
+				a, b := key("a"), key("b")
+
+				for i := int64(0); i <= limit.Rate; i++ {
+					store.Allow(ctx, a, limit, start)
+				}
+
+				res, err := store.Allow(ctx, b, limit, start)
+				assert.NoError(t, err)
+				assert.True(t, res.Allowed)
+			})
+		})
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	store := middleware.NewMemoryStore(4, time.Hour)
+	defer store.Close()
+
+	testRateLimitStore(t, store)
+}
+
+// TestRedisStore runs against an in-process redis, or against a real one
+// when REDIS_ADDR is set.
+func TestRedisStore(t *testing.T) {
+	addr, ok := os.LookupEnv("REDIS_ADDR")
+	if !ok {
+		addr = miniredis.RunT(t).Addr()
+	}
+
+	client := redis.NewClient(&redis.Options{Addr: addr})
+	defer client.Close()
+
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		t.Skipf("redis unavailable: %s", err)
+	}
+
+	testRateLimitStore(t, middleware.NewRedisStore(client))
+}
@@ -0,0 +1,157 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dreamsofcode-io/guestbook/internal/middleware"
+)
+
+func TestRateLimiterHeaders(t *testing.T) {
+	store := middleware.NewMemoryStore(1, time.Hour)
+	defer store.Close()
+
+	limiter := &middleware.RateLimiter{
+		Limit: middleware.Limit{
+			Algorithm: middleware.GCRA,
+			Rate:      2,
+			Period:    time.Minute,
+		},
+		Store: store,
+	}
+
+	handler := limiter.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	serve := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve()
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "2", w.Header().Get("X-RateLimit-Limit"))
+	assert.Equal(t, "1", w.Header().Get("X-RateLimit-Remaining"))
+	assert.Equal(t, "2", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", w.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "2;w=60", w.Header().Get("RateLimit-Policy"))
+	assert.Empty(t, w.Header().Get("Retry-After"))
+
+	serve()
+
+	w = serve()
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "30", w.Header().Get("Retry-After"))
+}
+
+func TestRateLimiterKeys(t *testing.T) {
+	store := middleware.NewMemoryStore(1, time.Hour)
+	defer store.Close()
+
+	limiter := &middleware.RateLimiter{
+		Name: "admin",
+		Limit: middleware.Limit{
+			Algorithm: middleware.FixedWindow,
+			Rate:      1,
+			Period:    time.Minute,
+		},
+		Store: store,
+		Key: middleware.KeyBySession("session", func(ctx context.Context, token string) bool {
+			return token == "one" || token == "two"
+		}),
+	}
+
+	handler := limiter.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	serve := func(session string) int {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if session != "" {
+			req.AddCookie(&http.Cookie{Name: "session", Value: session})
+		}
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, serve("one"))
+	assert.Equal(t, http.StatusTooManyRequests, serve("one"))
+	assert.Equal(t, http.StatusOK, serve("two"))
+
+	// Requests without a valid session share the client IP's count, so
+	// making up sessions doesn't get around it.
+	assert.Equal(t, http.StatusOK, serve(""))
+	assert.Equal(t, http.StatusTooManyRequests, serve(""))
+	assert.Equal(t, http.StatusTooManyRequests, serve("made-up"))
+}
+
+func TestRateLimiterAPIKeys(t *testing.T) {
+	store := middleware.NewMemoryStore(1, time.Hour)
+	defer store.Close()
+
+	limiter := &middleware.RateLimiter{
+		Name: "api",
+		Limit: middleware.Limit{
+			Algorithm: middleware.FixedWindow,
+			Rate:      1,
+			Period:    time.Minute,
+		},
+		Store: store,
+		Key: middleware.KeyByAPIKey(func(ctx context.Context, key string) bool {
+			return key == "one" || key == "two"
+		}),
+	}
+
+	handler := limiter.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	serve := func(header, value string) int {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, serve("Authorization", "Bearer one"))
+	assert.Equal(t, http.StatusTooManyRequests, serve("X-API-Key", "one"))
+	assert.Equal(t, http.StatusOK, serve("Authorization", "Bearer two"))
+
+	// Requests without a valid key share the client IP's count, so making
+	// up keys doesn't get around it.
+	assert.Equal(t, http.StatusOK, serve("", ""))
+	assert.Equal(t, http.StatusTooManyRequests, serve("", ""))
+	assert.Equal(t, http.StatusTooManyRequests, serve("Authorization", "Bearer made-up"))
+}
+
+func TestKeyByIP(t *testing.T) {
+	key := func(remoteAddr string) string {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = remoteAddr
+
+		return middleware.KeyByIP(req)
+	}
+
+	assert.Equal(t, "ip:192.0.2.1", key("192.0.2.1:1234"))
+	assert.NotEqual(t, key("192.0.2.1:1234"), key("192.0.2.2:1234"))
+
+	// Addresses in the same /64 share a count
+	assert.Equal(t, "ip:2001:db8::", key("[2001:db8::1]:1234"))
+	assert.Equal(t, key("[2001:db8::1]:1234"), key("[2001:db8::ffff:1]:1234"))
+	assert.NotEqual(t, key("[2001:db8::1]:1234"), key("[2001:db8:0:1::1]:1234"))
+}
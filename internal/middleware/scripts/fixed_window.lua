@@ -0,0 +1,34 @@
+-- Fixed window. A hash holds the start of the current window and how many
+-- requests have been allowed in it.
+--
+-- KEYS[1] the rate limit key
+-- ARGV[1] now, ARGV[2] period, ARGV[3] rate
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local period = tonumber(ARGV[2])
+local rate = tonumber(ARGV[3])
+
+local start = now - (now % period)
+local count = 0
+
+local state = redis.call('HMGET', key, 'start', 'count')
+if tonumber(state[1]) == start then
+  count = tonumber(state[2])
+end
+
+local allowed = 0
+if count < rate then
+  count = count + 1
+  allowed = 1
+end
+
+redis.call('HSET', key, 'start', start, 'count', count)
+redis.call('PEXPIREAT', key, math.ceil((start + period) / 1000))
+
+local reset = start + period - now
+local retry = 0
+if allowed == 0 then
+  retry = reset
+end
+
+return {allowed, rate - count, retry, reset}
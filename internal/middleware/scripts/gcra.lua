@@ -0,0 +1,28 @@
+-- Generic cell rate algorithm. The key holds the theoretical arrival time
+-- of the next request in microseconds.
+--
+-- KEYS[1] the rate limit key
+-- ARGV[1] now, ARGV[2] period, ARGV[3] rate, ARGV[4] burst
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local period = tonumber(ARGV[2])
+local rate = tonumber(ARGV[3])
+local burst = tonumber(ARGV[4])
+local emission = period / rate
+local tolerance = emission * burst
+
+local tat = tonumber(redis.call('GET', key))
+if not tat or tat < now then
+  tat = now
+end
+
+local new_tat = tat + emission
+local allow_at = new_tat - tolerance
+
+if now < allow_at then
+  return {0, 0, math.ceil(allow_at - now), math.ceil(tat - now)}
+end
+
+redis.call('SET', key, string.format('%.17g', new_tat), 'PX', math.ceil((new_tat - now) / 1000) + 1)
+
+return {1, math.floor((now - allow_at) / emission), 0, math.ceil(new_tat - now)}
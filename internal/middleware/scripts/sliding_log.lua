@@ -0,0 +1,41 @@
+-- Sliding window log. Every allowed request is a member of a sorted set
+-- scored by its time in microseconds.
+--
+-- KEYS[1] the rate limit key
+-- ARGV[1] now, ARGV[2] period, ARGV[3] rate, ARGV[4] unique member
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local period = tonumber(ARGV[2])
+local rate = tonumber(ARGV[3])
+
+redis.call('ZREMRANGEBYSCORE', key, '-inf', now - period)
+
+local count = redis.call('ZCARD', key)
+local allowed = 0
+if count < rate then
+  redis.call('ZADD', key, now, ARGV[4])
+  count = count + 1
+  allowed = 1
+end
+
+local oldest = now
+local first = redis.call('ZRANGE', key, 0, 0, 'WITHSCORES')
+if first[2] then
+  oldest = tonumber(first[2])
+end
+
+local newest = now
+local last = redis.call('ZRANGE', key, -1, -1, 'WITHSCORES')
+if last[2] then
+  newest = tonumber(last[2])
+end
+
+redis.call('PEXPIREAT', key, math.ceil((newest + period) / 1000))
+
+local reset = oldest + period - now
+local retry = 0
+if allowed == 0 then
+  retry = reset
+end
+
+return {allowed, rate - count, retry, reset}
@@ -0,0 +1,39 @@
+-- Token bucket. A hash holds the tokens left and when they were last
+-- refilled. Tokens are fractional, so they are stored as strings.
+--
+-- KEYS[1] the rate limit key
+-- ARGV[1] now, ARGV[2] period, ARGV[3] rate, ARGV[4] burst
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local period = tonumber(ARGV[2])
+local rate = tonumber(ARGV[3])
+local burst = tonumber(ARGV[4])
+local emission = period / rate
+
+local tokens = burst
+local last = now
+
+local state = redis.call('HMGET', key, 'tokens', 'last')
+if state[1] then
+  tokens = tonumber(state[1])
+  last = tonumber(state[2])
+end
+
+local elapsed = math.max(0, now - last)
+tokens = math.min(burst, tokens + elapsed / emission)
+
+local allowed = 0
+local retry = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+else
+  retry = math.ceil((1 - tokens) * emission)
+end
+
+local reset = math.ceil((burst - tokens) * emission)
+
+redis.call('HSET', key, 'tokens', string.format('%.17g', tokens), 'last', now)
+redis.call('PEXPIREAT', key, math.ceil((now + reset) / 1000) + 1)
+
+return {allowed, math.floor(tokens), retry, reset}
@@ -0,0 +1,79 @@
+// Package moderation contains the actions moderators can take on guest
+// entries.
+package moderation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/dreamsofcode-io/guestbook/internal/repository"
+)
+
+var (
+	ErrNotFound         = errors.New("guest not found")
+	ErrMissingModerator = errors.New("moderator is required")
+)
+
+// Moderator records moderation decisions against guest entries.
+type Moderator struct {
+	repo *repository.Queries
+}
+
+func New(db repository.DBTX) *Moderator {
+	return &Moderator{
+		repo: repository.New(db),
+	}
+}
+
+// Pending returns the oldest entries that are waiting for a decision.
+func (m *Moderator) Pending(ctx context.Context, limit int32) ([]repository.Guest, error) {
+	guests, err := m.repo.FindPending(ctx, limit)
+	if err != nil {
+		return nil, fmt.Errorf("find pending: %w", err)
+	}
+
+	return guests, nil
+}
+
+// Approve publishes the entry with the given id.
+func (m *Moderator) Approve(
+	ctx context.Context, id uuid.UUID, moderator string,
+) (repository.Guest, error) {
+	return m.decide(ctx, id, repository.GuestStatusApproved, moderator)
+}
+
+// Reject hides the entry with the given id.
+func (m *Moderator) Reject(
+	ctx context.Context, id uuid.UUID, moderator string,
+) (repository.Guest, error) {
+	return m.decide(ctx, id, repository.GuestStatusRejected, moderator)
+}
+
+func (m *Moderator) decide(
+	ctx context.Context, id uuid.UUID, status repository.GuestStatus,
+	moderator string,
+) (repository.Guest, error) {
+	if moderator == "" {
+		return repository.Guest{}, ErrMissingModerator
+	}
+
+	guest, err := m.repo.SetStatus(ctx, repository.SetStatusParams{
+		ID:          id,
+		Status:      status,
+		ModeratedBy: pgtype.Text{String: moderator, Valid: true},
+		ModeratedAt: pgtype.Timestamptz{Time: time.Now().UTC(), Valid: true},
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return repository.Guest{}, ErrNotFound
+	} else if err != nil {
+		return repository.Guest{}, fmt.Errorf("set status: %w", err)
+	}
+
+	return guest, nil
+}
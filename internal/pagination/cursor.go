@@ -0,0 +1,71 @@
+// Package pagination provides the opaque keyset cursors used to page
+// through guest entries.
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidCursor is returned when a cursor string cannot be decoded.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+const cursorLen = 8 + 16
+
+// Cursor marks a position in the guest feed using the (created_at, id)
+// keyset of a single entry.
+type Cursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+// New creates a cursor pointing at the entry with the given creation time
+// and id.
+func New(createdAt time.Time, id uuid.UUID) Cursor {
+	return Cursor{
+		CreatedAt: createdAt,
+		ID:        id,
+	}
+}
+
+// Encode serializes the cursor into an opaque, url safe string. Timestamps
+// are stored with microsecond precision to match postgres.
+func (c Cursor) Encode() string {
+	buf := make([]byte, cursorLen)
+	binary.BigEndian.PutUint64(buf[:8], uint64(c.CreatedAt.UnixMicro()))
+	copy(buf[8:], c.ID[:])
+
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
+
+// Decode parses a cursor previously produced by Encode.
+func Decode(s string) (Cursor, error) {
+	buf, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	return decode(buf)
+}
+
+func decode(buf []byte) (Cursor, error) {
+	if len(buf) != cursorLen {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	micro := int64(binary.BigEndian.Uint64(buf[:8]))
+
+	id, err := uuid.FromBytes(buf[8:])
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	return Cursor{
+		CreatedAt: time.UnixMicro(micro).UTC(),
+		ID:        id,
+	}, nil
+}
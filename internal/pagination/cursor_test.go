@@ -0,0 +1,62 @@
+package pagination_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dreamsofcode-io/guestbook/internal/pagination"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	id := uuid.Must(uuid.NewV7())
+	createdAt := time.Date(2024, 10, 21, 13, 4, 5, 123456000, time.UTC)
+
+	encoded := pagination.New(createdAt, id).Encode()
+
+	cursor, err := pagination.Decode(encoded)
+	assert.NoError(t, err)
+	assert.Equal(t, id, cursor.ID)
+	assert.True(t, createdAt.Equal(cursor.CreatedAt))
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	testCases := []struct {
+		Description string
+		Input       string
+	}{
+		{Description: "empty", Input: ""},
+		{Description: "not base64", Input: "!!!"},
+		{Description: "too short", Input: "AAAA"},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.Description, func(t *testing.T) {
+			_, err := pagination.Decode(test.Input)
+			assert.ErrorIs(t, err, pagination.ErrInvalidCursor)
+		})
+	}
+}
+
+func TestSearchCursorRoundTrip(t *testing.T) {
+	id := uuid.Must(uuid.NewV7())
+	createdAt := time.Date(2024, 11, 16, 9, 0, 0, 654321000, time.UTC)
+	rank := float32(0.0607927)
+
+	encoded := pagination.NewSearch(rank, pagination.New(createdAt, id)).Encode()
+
+	cursor, err := pagination.DecodeSearch(encoded)
+	assert.NoError(t, err)
+	assert.Equal(t, rank, cursor.Rank)
+	assert.Equal(t, id, cursor.ID)
+	assert.True(t, createdAt.Equal(cursor.CreatedAt))
+
+	// A plain cursor is not a search cursor
+	_, err = pagination.DecodeSearch(pagination.New(createdAt, id).Encode())
+	assert.ErrorIs(t, err, pagination.ErrInvalidCursor)
+
+	_, err = pagination.DecodeSearch("/8AAAA" + encoded[6:])
+	assert.ErrorIs(t, err, pagination.ErrInvalidCursor)
+}
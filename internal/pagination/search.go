@@ -0,0 +1,55 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"math"
+)
+
+const searchCursorLen = 4 + cursorLen
+
+// SearchCursor marks a position in search results, which are ordered by
+// rank before the (created_at, id) keyset.
+type SearchCursor struct {
+	Rank float32
+	Cursor
+}
+
+// NewSearch creates a cursor pointing at a search result.
+func NewSearch(rank float32, cursor Cursor) SearchCursor {
+	return SearchCursor{
+		Rank:   rank,
+		Cursor: cursor,
+	}
+}
+
+// Encode serializes the cursor into an opaque, url safe string. The rank is
+// stored exactly so it compares equal to the rank computed by postgres.
+func (c SearchCursor) Encode() string {
+	buf := make([]byte, searchCursorLen)
+	binary.BigEndian.PutUint32(buf[:4], math.Float32bits(c.Rank))
+	binary.BigEndian.PutUint64(buf[4:12], uint64(c.CreatedAt.UnixMicro()))
+	copy(buf[12:], c.ID[:])
+
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
+
+// DecodeSearch parses a cursor previously produced by SearchCursor.Encode.
+func DecodeSearch(s string) (SearchCursor, error) {
+	buf, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(buf) != searchCursorLen {
+		return SearchCursor{}, ErrInvalidCursor
+	}
+
+	rank := math.Float32frombits(binary.BigEndian.Uint32(buf[:4]))
+	if math.IsNaN(float64(rank)) || math.IsInf(float64(rank), 0) {
+		return SearchCursor{}, ErrInvalidCursor
+	}
+
+	cursor, err := decode(buf[4:])
+	if err != nil {
+		return SearchCursor{}, err
+	}
+
+	return NewSearch(rank, cursor), nil
+}
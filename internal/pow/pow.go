@@ -0,0 +1,307 @@
+// Package pow issues and checks the proof of work challenges browsers solve
+// before posting. Solving one takes a moment for a visitor but adds up for
+// a bot posting in bulk, and nothing about the visitor is stored.
+package pow
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/bits"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/dreamsofcode-io/guestbook/internal/config"
+	"github.com/dreamsofcode-io/guestbook/internal/middleware"
+)
+
+const (
+	// ChallengeField and SolutionField are the form fields the script
+	// fills in.
+	ChallengeField = "pow_challenge"
+	SolutionField  = "pow_solution"
+)
+
+// maxSolutionLength keeps solutions to a counter a browser could have
+// reached.
+const maxSolutionLength = 20
+
+var (
+	ErrMissing  = errors.New("proof of work is missing")
+	ErrInvalid  = errors.New("proof of work challenge is invalid")
+	ErrExpired  = errors.New("proof of work challenge has expired")
+	ErrUnsolved = errors.New("proof of work is not solved")
+	ErrReplayed = errors.New("proof of work challenge has already been used")
+	// ErrUnavailable is returned when redis can't be reached to check a
+	// challenge hasn't been used, and the failure policy is closed.
+	ErrUnavailable = errors.New("proof of work can't be checked")
+)
+
+// Challenge is a signed challenge along with how hard it is to solve.
+type Challenge struct {
+	Value      string
+	Difficulty int
+	Expires    time.Time
+}
+
+// Issuer hands out challenges and checks their solutions. Used challenges
+// and the recent posting volume are kept in redis, so they are shared by
+// every replica. Calls to redis are bounded by a timeout and skipped while
+// the breaker is open.
+type Issuer struct {
+	logger  *slog.Logger
+	key     []byte
+	rdb     *redis.Client
+	breaker *middleware.Breaker
+	cfg     *config.ProofOfWork
+}
+
+func New(
+	logger *slog.Logger, key []byte, rdb *redis.Client, breaker *middleware.Breaker,
+	cfg *config.ProofOfWork,
+) *Issuer {
+	return &Issuer{
+		logger:  logger,
+		key:     key,
+		rdb:     rdb,
+		breaker: breaker,
+		cfg:     cfg,
+	}
+}
+
+// Enabled reports whether posting requires a challenge to be solved.
+func (i *Issuer) Enabled() bool {
+	return i != nil && i.cfg.Difficulty > 0
+}
+
+// APIEnabled reports whether messages sent through the JSON API and
+// websocket need a solved challenge as well.
+func (i *Issuer) APIEnabled() bool {
+	return i.Enabled() && i.cfg.API
+}
+
+// Difficulty returns the difficulty for a posting rate, rising by one bit
+// each time the rate doubles beyond the surge rate.
+func Difficulty(cfg *config.ProofOfWork, rate int) int {
+	if rate < cfg.SurgeRate {
+		return cfg.Difficulty
+	}
+
+	return min(cfg.Difficulty+bits.Len(uint(rate/cfg.SurgeRate)), cfg.MaxDifficulty)
+}
+
+func (i *Issuer) sign(payload string) string {
+	mac := hmac.New(sha256.New, i.key)
+	mac.Write([]byte("pow:"))
+	mac.Write([]byte(payload))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// call runs fn against redis with the configured timeout, unless the
+// breaker is open.
+func (i *Issuer) call(ctx context.Context, fn func(ctx context.Context) error) error {
+	if !i.breaker.Allow(time.Now()) {
+		return ErrUnavailable
+	}
+
+	callCtx := ctx
+	if i.cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		callCtx, cancel = context.WithTimeout(ctx, i.cfg.Timeout)
+		defer cancel()
+	}
+
+	if err := fn(callCtx); err != nil {
+		// The client going away says nothing about redis' health
+		if ctx.Err() != nil {
+			i.breaker.Cancel()
+			return ctx.Err()
+		}
+
+		i.breaker.Failure(time.Now())
+
+		return err
+	}
+
+	i.breaker.Success()
+
+	return nil
+}
+
+func postsKey(minute int64) string {
+	return "pow:posts:" + strconv.FormatInt(minute, 10)
+}
+
+// rate estimates the number of posts over the last minute, weighting the
+// previous minute by how much of it is still inside the window.
+func (i *Issuer) rate(ctx context.Context, now time.Time) (int, error) {
+	minute := now.Unix() / 60
+
+	var values []any
+	err := i.call(ctx, func(ctx context.Context) error {
+		var err error
+		values, err = i.rdb.MGet(ctx, postsKey(minute-1), postsKey(minute)).Result()
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("get posting rate: %w", err)
+	}
+
+	var counts [2]int
+	for n, value := range values {
+		if s, ok := value.(string); ok {
+			counts[n], _ = strconv.Atoi(s)
+		}
+	}
+
+	elapsed := float64(now.Unix()%60) / 60
+
+	return int(float64(counts[0])*(1-elapsed)) + counts[1], nil
+}
+
+// Issue creates a challenge, as hard as the current posting volume calls
+// for.
+func (i *Issuer) Issue(ctx context.Context, now time.Time) (Challenge, error) {
+	difficulty := i.cfg.Difficulty
+
+	rate, err := i.rate(ctx, now)
+	if err != nil {
+		i.logger.Warn("failed to get posting rate", slog.Any("error", err))
+	} else {
+		difficulty = Difficulty(i.cfg, rate)
+	}
+
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return Challenge{}, fmt.Errorf("generate nonce: %w", err)
+	}
+
+	expires := now.Add(i.cfg.TTL)
+	payload := strings.Join([]string{
+		base64.RawURLEncoding.EncodeToString(buf),
+		strconv.FormatInt(expires.Unix(), 10),
+		strconv.Itoa(difficulty),
+	}, ".")
+
+	return Challenge{
+		Value:      payload + "." + i.sign(payload),
+		Difficulty: difficulty,
+		Expires:    expires,
+	}, nil
+}
+
+// parse checks a challenge was issued by us and returns its nonce, expiry
+// and difficulty.
+func (i *Issuer) parse(value string) (string, time.Time, int, error) {
+	idx := strings.LastIndexByte(value, '.')
+	if idx < 0 || !hmac.Equal([]byte(value[idx+1:]), []byte(i.sign(value[:idx]))) {
+		return "", time.Time{}, 0, ErrInvalid
+	}
+
+	payload := value[:idx]
+
+	parts := strings.Split(payload, ".")
+	if len(parts) != 3 {
+		return "", time.Time{}, 0, ErrInvalid
+	}
+
+	expires, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return "", time.Time{}, 0, ErrInvalid
+	}
+
+	difficulty, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return "", time.Time{}, 0, ErrInvalid
+	}
+
+	return parts[0], time.Unix(expires, 0), difficulty, nil
+}
+
+// Solves reports whether a solution's hash has at least as many leading
+// zero bits as the difficulty asks for.
+func Solves(challenge, solution string, difficulty int) bool {
+	sum := sha256.Sum256([]byte(challenge + ":" + solution))
+
+	zeros := 0
+	for _, b := range sum {
+		zeros += bits.LeadingZeros8(b)
+		if b != 0 {
+			break
+		}
+	}
+
+	return zeros >= difficulty
+}
+
+// Verify checks a solution to a challenge, which can only be used once.
+func (i *Issuer) Verify(ctx context.Context, challenge, solution string, now time.Time) error {
+	if challenge == "" || solution == "" {
+		return ErrMissing
+	}
+
+	nonce, expires, difficulty, err := i.parse(challenge)
+	if err != nil {
+		return err
+	}
+
+	if !now.Before(expires) {
+		return ErrExpired
+	}
+
+	if len(solution) > maxSolutionLength || !Solves(challenge, solution, difficulty) {
+		return ErrUnsolved
+	}
+
+	// Keep the challenge as used until it would have expired anyway. Should
+	// redis be unavailable the solution is accepted when failing open, as
+	// it has been signed and paid for, and refused when failing closed.
+	var claimed bool
+	err = i.call(ctx, func(ctx context.Context) error {
+		var err error
+		claimed, err = i.rdb.SetNX(ctx, "pow:used:"+nonce, 1, expires.Sub(now)).Result()
+		return err
+	})
+	if err != nil {
+		i.logger.Warn("failed to claim proof of work",
+			slog.Any("error", err),
+			slog.String("failure", i.cfg.Failure),
+		)
+
+		if i.cfg.Failure == config.PowFailClosed {
+			return ErrUnavailable
+		}
+	} else if !claimed {
+		return ErrReplayed
+	}
+
+	i.count(ctx, now)
+
+	return nil
+}
+
+// count adds a post to the posting volume.
+func (i *Issuer) count(ctx context.Context, now time.Time) {
+	key := postsKey(now.Unix() / 60)
+
+	err := i.call(ctx, func(ctx context.Context) error {
+		pipe := i.rdb.TxPipeline()
+		pipe.Incr(ctx, key)
+		pipe.Expire(ctx, key, 2*time.Minute)
+
+		_, err := pipe.Exec(ctx)
+		return err
+	})
+	if err != nil {
+		i.logger.Warn("failed to count post", slog.Any("error", err))
+	}
+}
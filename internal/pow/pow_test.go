@@ -0,0 +1,170 @@
+package pow_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dreamsofcode-io/guestbook/internal/config"
+	"github.com/dreamsofcode-io/guestbook/internal/middleware"
+	"github.com/dreamsofcode-io/guestbook/internal/pow"
+)
+
+func newIssuer(t *testing.T, cfg *config.ProofOfWork) *pow.Issuer {
+	t.Helper()
+
+	issuer, _ := newIssuerWithServer(t, middleware.NewBreaker(5, time.Minute), cfg)
+
+	return issuer
+}
+
+func newIssuerWithServer(
+	t *testing.T, breaker *middleware.Breaker, cfg *config.ProofOfWork,
+) (*pow.Issuer, *miniredis.Miniredis) {
+	t.Helper()
+
+	srv := miniredis.RunT(t)
+
+	client := redis.NewClient(&redis.Options{Addr: srv.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	return pow.New(logger, []byte("0123456789abcdef0123456789abcdef"), client, breaker, cfg), srv
+}
+
+// solve does what the script in the browser does.
+func solve(c pow.Challenge) string {
+	for n := 0; ; n++ {
+		if solution := strconv.Itoa(n); pow.Solves(c.Value, solution, c.Difficulty) {
+			return solution
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	issuer := newIssuer(t, &config.ProofOfWork{
+		Difficulty: 8, MaxDifficulty: 8, SurgeRate: 20, TTL: time.Minute,
+	})
+
+	c, err := issuer.Issue(ctx, now)
+	require.NoError(t, err)
+	assert.Equal(t, 8, c.Difficulty)
+
+	solution := solve(c)
+
+	assert.ErrorIs(t, issuer.Verify(ctx, "", "", now), pow.ErrMissing)
+	assert.ErrorIs(t, issuer.Verify(ctx, c.Value, solution, now.Add(time.Minute)), pow.ErrExpired)
+
+	tampered := strings.Replace(c.Value, ".8.", ".1.", 1)
+	assert.ErrorIs(t, issuer.Verify(ctx, tampered, solution, now), pow.ErrInvalid)
+	assert.ErrorIs(t, issuer.Verify(ctx, "not a challenge", solution, now), pow.ErrInvalid)
+
+	for n := 0; ; n++ {
+		if wrong := strconv.Itoa(n); !pow.Solves(c.Value, wrong, c.Difficulty) {
+			assert.ErrorIs(t, issuer.Verify(ctx, c.Value, wrong, now), pow.ErrUnsolved)
+			break
+		}
+	}
+
+	require.NoError(t, issuer.Verify(ctx, c.Value, solution, now))
+	assert.ErrorIs(t, issuer.Verify(ctx, c.Value, solution, now), pow.ErrReplayed)
+}
+
+func TestVerifyUnavailable(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	testCases := []struct {
+		Description string
+		Failure     string
+		Err         error
+	}{
+		{
+			Description: "failing open accepts solved challenges",
+			Failure:     config.PowFailOpen,
+		},
+		{
+			Description: "failing closed refuses them",
+			Failure:     config.PowFailClosed,
+			Err:         pow.ErrUnavailable,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Description, func(t *testing.T) {
+			breaker := middleware.NewBreaker(1, time.Minute)
+			issuer, srv := newIssuerWithServer(t, breaker, &config.ProofOfWork{
+				Difficulty: 8, MaxDifficulty: 8, SurgeRate: 20, TTL: time.Minute,
+				Failure: tc.Failure, Timeout: 100 * time.Millisecond,
+			})
+
+			c, err := issuer.Issue(ctx, now)
+			require.NoError(t, err)
+
+			srv.Close()
+
+			assert.Equal(t, tc.Err, issuer.Verify(ctx, c.Value, solve(c), now))
+			assert.Equal(t, middleware.BreakerOpen, breaker.State())
+
+			// Issuing still works with the breaker open, at the base
+			// difficulty.
+			c, err = issuer.Issue(ctx, now)
+			require.NoError(t, err)
+			assert.Equal(t, 8, c.Difficulty)
+		})
+	}
+}
+
+func TestIssueRamp(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	issuer := newIssuer(t, &config.ProofOfWork{
+		Difficulty: 1, MaxDifficulty: 4, SurgeRate: 2, TTL: time.Minute,
+	})
+
+	for range 4 {
+		c, err := issuer.Issue(ctx, now)
+		require.NoError(t, err)
+		require.NoError(t, issuer.Verify(ctx, c.Value, solve(c), now))
+	}
+
+	c, err := issuer.Issue(ctx, now)
+	require.NoError(t, err)
+	assert.Equal(t, 3, c.Difficulty)
+}
+
+func TestDifficulty(t *testing.T) {
+	cfg := &config.ProofOfWork{Difficulty: 16, MaxDifficulty: 20, SurgeRate: 10}
+
+	testCases := []struct {
+		Rate     int
+		Expected int
+	}{
+		{Rate: 0, Expected: 16},
+		{Rate: 9, Expected: 16},
+		{Rate: 10, Expected: 17},
+		{Rate: 19, Expected: 17},
+		{Rate: 20, Expected: 18},
+		{Rate: 40, Expected: 19},
+		{Rate: 80, Expected: 20},
+		{Rate: 10000, Expected: 20},
+	}
+
+	for _, tc := range testCases {
+		t.Run(strconv.Itoa(tc.Rate), func(t *testing.T) {
+			assert.Equal(t, tc.Expected, pow.Difficulty(cfg, tc.Rate))
+		})
+	}
+}
@@ -0,0 +1,153 @@
+// Package reaction stores the emoji reactions visitors leave on entries.
+package reaction
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/dreamsofcode-io/guestbook/internal/clientip"
+	"github.com/dreamsofcode-io/guestbook/internal/repository"
+)
+
+var (
+	ErrNotFound    = errors.New("guest not found")
+	ErrInvalidKind = errors.New("invalid reaction")
+)
+
+// Kinds lists the reactions visitors can leave, in the order they are
+// shown.
+var Kinds = []repository.ReactionKind{
+	repository.ReactionKindLike,
+	repository.ReactionKindHeart,
+	repository.ReactionKindLaugh,
+	repository.ReactionKindWow,
+	repository.ReactionKindParty,
+}
+
+var emoji = map[repository.ReactionKind]string{
+	repository.ReactionKindLike:  "👍",
+	repository.ReactionKindHeart: "❤️",
+	repository.ReactionKindLaugh: "😂",
+	repository.ReactionKindWow:   "😮",
+	repository.ReactionKindParty: "🎉",
+}
+
+// ParseKind returns the reaction with the given name.
+func ParseKind(s string) (repository.ReactionKind, error) {
+	kind := repository.ReactionKind(s)
+	if _, ok := emoji[kind]; !ok {
+		return "", ErrInvalidKind
+	}
+
+	return kind, nil
+}
+
+// Count is the number of times an entry received one kind of reaction.
+type Count struct {
+	Kind  repository.ReactionKind
+	Emoji string
+	Count int64
+}
+
+// None returns a zero count for every kind, in display order.
+func None() []Count {
+	res := make([]Count, 0, len(Kinds))
+	for _, kind := range Kinds {
+		res = append(res, Count{Kind: kind, Emoji: emoji[kind]})
+	}
+
+	return res
+}
+
+// Store records reactions and counts them.
+type Store struct {
+	repo *repository.Queries
+	key  []byte
+}
+
+// New creates a store that hashes visitor identities with the given key.
+func New(db repository.DBTX, key []byte) *Store {
+	return &Store{
+		repo: repository.New(db),
+		key:  key,
+	}
+}
+
+// Visitor returns the hashed identity a reaction is recorded against. It is
+// derived from the client's subnet, so a visitor can't react again just by
+// picking another IPv6 address from their range. Only the hash is stored.
+func (s *Store) Visitor(ip net.IP) []byte {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte("reaction:"))
+	mac.Write(clientip.Subnet(ip))
+
+	return mac.Sum(nil)
+}
+
+// Add records a reaction to an approved entry. Each visitor can leave each
+// kind of reaction once, so it returns false when they already had.
+func (s *Store) Add(
+	ctx context.Context, guestID uuid.UUID, kind repository.ReactionKind,
+	ip net.IP,
+) (bool, error) {
+	guest, err := s.repo.FindByID(ctx, guestID)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && guest.Status != repository.GuestStatusApproved) {
+		return false, ErrNotFound
+	} else if err != nil {
+		return false, fmt.Errorf("find guest: %w", err)
+	}
+
+	added, err := s.repo.AddReaction(ctx, repository.AddReactionParams{
+		GuestID:   guestID,
+		Kind:      kind,
+		Visitor:   s.Visitor(ip),
+		CreatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return false, fmt.Errorf("add reaction: %w", err)
+	}
+
+	return added > 0, nil
+}
+
+// Counts returns the reaction counts for each of the given entries, with
+// every kind present in display order.
+func (s *Store) Counts(
+	ctx context.Context, ids []uuid.UUID,
+) (map[uuid.UUID][]Count, error) {
+	res := make(map[uuid.UUID][]Count, len(ids))
+	if len(ids) == 0 {
+		return res, nil
+	}
+
+	rows, err := s.repo.CountReactions(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("count reactions: %w", err)
+	}
+
+	counts := make(map[uuid.UUID]map[repository.ReactionKind]int64, len(ids))
+	for _, row := range rows {
+		if counts[row.GuestID] == nil {
+			counts[row.GuestID] = make(map[repository.ReactionKind]int64)
+		}
+
+		counts[row.GuestID][row.Kind] = row.Count
+	}
+
+	for _, id := range ids {
+		res[id] = None()
+		for i := range res[id] {
+			res[id][i].Count = counts[id][res[id][i].Kind]
+		}
+	}
+
+	return res, nil
+}
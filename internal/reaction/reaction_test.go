@@ -0,0 +1,34 @@
+package reaction_test
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dreamsofcode-io/guestbook/internal/reaction"
+	"github.com/dreamsofcode-io/guestbook/internal/repository"
+)
+
+func TestParseKind(t *testing.T) {
+	kind, err := reaction.ParseKind("heart")
+	assert.NoError(t, err)
+	assert.Equal(t, repository.ReactionKindHeart, kind)
+
+	_, err = reaction.ParseKind("poop")
+	assert.ErrorIs(t, err, reaction.ErrInvalidKind)
+}
+
+func TestVisitor(t *testing.T) {
+	store := reaction.New(nil, []byte("secret"))
+	other := reaction.New(nil, []byte("another secret"))
+
+	v4 := store.Visitor(net.ParseIP("192.0.2.1"))
+	assert.Equal(t, v4, store.Visitor(net.ParseIP("::ffff:192.0.2.1")))
+	assert.NotEqual(t, v4, store.Visitor(net.ParseIP("192.0.2.2")))
+	assert.NotEqual(t, v4, other.Visitor(net.ParseIP("192.0.2.1")))
+
+	v6 := store.Visitor(net.ParseIP("2001:db8::1"))
+	assert.Equal(t, v6, store.Visitor(net.ParseIP("2001:db8::ffff:1")))
+	assert.NotEqual(t, v6, store.Visitor(net.ParseIP("2001:db8:0:1::1")))
+}
@@ -5,16 +5,180 @@
 package repository
 
 import (
+	"database/sql/driver"
+	"fmt"
 	"net"
+	"net/netip"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
-type Guest struct {
+type GuestStatus string
+
+const (
+	GuestStatusPending  GuestStatus = "pending"
+	GuestStatusApproved GuestStatus = "approved"
+	GuestStatusRejected GuestStatus = "rejected"
+)
+
+func (e *GuestStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = GuestStatus(s)
+	case string:
+		*e = GuestStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for GuestStatus: %T", src)
+	}
+	return nil
+}
+
+type NullGuestStatus struct {
+	GuestStatus GuestStatus
+	Valid       bool // Valid is true if GuestStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullGuestStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.GuestStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.GuestStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullGuestStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.GuestStatus), nil
+}
+
+type ReactionKind string
+
+const (
+	ReactionKindLike  ReactionKind = "like"
+	ReactionKindHeart ReactionKind = "heart"
+	ReactionKindLaugh ReactionKind = "laugh"
+	ReactionKindWow   ReactionKind = "wow"
+	ReactionKindParty ReactionKind = "party"
+)
+
+func (e *ReactionKind) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = ReactionKind(s)
+	case string:
+		*e = ReactionKind(s)
+	default:
+		return fmt.Errorf("unsupported scan type for ReactionKind: %T", src)
+	}
+	return nil
+}
+
+type NullReactionKind struct {
+	ReactionKind ReactionKind
+	Valid        bool // Valid is true if ReactionKind is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullReactionKind) Scan(value interface{}) error {
+	if value == nil {
+		ns.ReactionKind, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.ReactionKind.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullReactionKind) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.ReactionKind), nil
+}
+
+type AdminSession struct {
+	TokenHash []byte
+	AdminID   uuid.UUID
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+type AdminUser struct {
+	ID           uuid.UUID
+	Username     string
+	PasswordHash string
+	CreatedAt    time.Time
+}
+
+type APIKey struct {
 	ID        uuid.UUID
-	Message   string
-	Ip        net.IP
+	Name      string
+	KeyHash   []byte
+	CreatedBy string
+	CreatedAt time.Time
+}
+
+type Ban struct {
+	ID        uuid.UUID
+	Network   netip.Prefix
+	Reason    string
+	CreatedBy string
+	CreatedAt time.Time
+	ExpiresAt pgtype.Timestamptz
+}
+
+type DiscardedPost struct {
+	Day    pgtype.Date
+	Reason string
+	Count  int64
+}
+
+type Guest struct {
+	ID            uuid.UUID
+	Message       string
+	Ip            net.IP
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	Status        GuestStatus
+	ModeratedBy   pgtype.Text
+	ModeratedAt   pgtype.Timestamptz
+	Search        interface{}
+	ParentID      pgtype.UUID
+	Depth         int32
+	Name          string
+	Website       string
+	EditTokenHash []byte
+	GuestbookID   uuid.UUID
+}
+
+type GuestEdit struct {
+	ID       int64
+	GuestID  uuid.UUID
+	Message  string
+	EditedAt time.Time
+}
+
+type Guestbook struct {
+	ID            uuid.UUID
+	Slug          string
+	Title         string
+	Description   string
+	PreModeration bool
+	MaxLength     int32
+	Open          bool
+	CreatedAt     time.Time
+}
+
+type Reaction struct {
+	GuestID   uuid.UUID
+	Kind      ReactionKind
+	Visitor   []byte
 	CreatedAt time.Time
-	UpdatedAt time.Time
 }
@@ -8,11 +8,39 @@ package repository
 import (
 	"context"
 	"net"
+	"net/netip"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const addReaction = `-- name: AddReaction :execrows
+INSERT INTO reaction (guest_id, kind, visitor, created_at)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT DO NOTHING
+`
+
+type AddReactionParams struct {
+	GuestID   uuid.UUID
+	Kind      ReactionKind
+	Visitor   []byte
+	CreatedAt time.Time
+}
+
+func (q *Queries) AddReaction(ctx context.Context, arg AddReactionParams) (int64, error) {
+	result, err := q.db.Exec(ctx, addReaction,
+		arg.GuestID,
+		arg.Kind,
+		arg.Visitor,
+		arg.CreatedAt,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const count = `-- name: Count :one
 SELECT COUNT(*) FROM guest
 `
@@ -24,15 +52,961 @@ func (q *Queries) Count(ctx context.Context) (int64, error) {
 	return count, err
 }
 
-const findAll = `-- name: FindAll :many
-SELECT id, message, ip, created_at, updated_at
+const countApproved = `-- name: CountApproved :one
+SELECT COUNT(*) FROM guest
+WHERE guestbook_id = $1
+  AND parent_id IS NULL
+  AND status = 'approved'
+`
+
+func (q *Queries) CountApproved(ctx context.Context, guestbookID uuid.UUID) (int64, error) {
+	row := q.db.QueryRow(ctx, countApproved, guestbookID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countDiscarded = `-- name: CountDiscarded :exec
+INSERT INTO discarded_post (day, reason, count)
+VALUES (CURRENT_DATE, $1, 1)
+ON CONFLICT (day, reason) DO UPDATE SET count = discarded_post.count + 1
+`
+
+func (q *Queries) CountDiscarded(ctx context.Context, reason string) error {
+	_, err := q.db.Exec(ctx, countDiscarded, reason)
+	return err
+}
+
+const countReactions = `-- name: CountReactions :many
+SELECT guest_id, kind, COUNT(*) AS count
+FROM reaction
+WHERE guest_id = ANY($1::uuid[])
+GROUP BY guest_id, kind
+`
+
+type CountReactionsRow struct {
+	GuestID uuid.UUID
+	Kind    ReactionKind
+	Count   int64
+}
+
+func (q *Queries) CountReactions(ctx context.Context, ids []uuid.UUID) ([]CountReactionsRow, error) {
+	rows, err := q.db.Query(ctx, countReactions, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CountReactionsRow
+	for rows.Next() {
+		var i CountReactionsRow
+		if err := rows.Scan(
+			&i.GuestID,
+			&i.Kind,
+			&i.Count,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const countReplies = `-- name: CountReplies :many
+SELECT parent_id::uuid AS parent_id, COUNT(*) AS count
+FROM guest
+WHERE parent_id = ANY($1::uuid[])
+  AND status = 'approved'
+GROUP BY parent_id
+`
+
+type CountRepliesRow struct {
+	ParentID uuid.UUID
+	Count    int64
+}
+
+func (q *Queries) CountReplies(ctx context.Context, ids []uuid.UUID) ([]CountRepliesRow, error) {
+	rows, err := q.db.Query(ctx, countReplies, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CountRepliesRow
+	for rows.Next() {
+		var i CountRepliesRow
+		if err := rows.Scan(
+			&i.ParentID,
+			&i.Count,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const countSearch = `-- name: CountSearch :one
+SELECT COUNT(*) FROM guest
+WHERE guestbook_id = $1::uuid
+  AND status = 'approved'
+  AND search @@ websearch_to_tsquery('english', $2::text)
+`
+
+type CountSearchParams struct {
+	GuestbookID uuid.UUID
+	Query       string
+}
+
+func (q *Queries) CountSearch(ctx context.Context, arg CountSearchParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countSearch, arg.GuestbookID, arg.Query)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createAPIKey = `-- name: CreateAPIKey :one
+INSERT INTO api_key (id, name, key_hash, created_by, created_at)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, name, key_hash, created_by, created_at
+`
+
+type CreateAPIKeyParams struct {
+	ID        uuid.UUID
+	Name      string
+	KeyHash   []byte
+	CreatedBy string
+	CreatedAt time.Time
+}
+
+func (q *Queries) CreateAPIKey(ctx context.Context, arg CreateAPIKeyParams) (APIKey, error) {
+	row := q.db.QueryRow(ctx, createAPIKey,
+		arg.ID,
+		arg.Name,
+		arg.KeyHash,
+		arg.CreatedBy,
+		arg.CreatedAt,
+	)
+	var i APIKey
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.KeyHash,
+		&i.CreatedBy,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createAdmin = `-- name: CreateAdmin :one
+INSERT INTO admin_user (id, username, password_hash, created_at)
+VALUES ($1, $2, $3, $4)
+RETURNING id, username, password_hash, created_at
+`
+
+type CreateAdminParams struct {
+	ID           uuid.UUID
+	Username     string
+	PasswordHash string
+	CreatedAt    time.Time
+}
+
+func (q *Queries) CreateAdmin(ctx context.Context, arg CreateAdminParams) (AdminUser, error) {
+	row := q.db.QueryRow(ctx, createAdmin,
+		arg.ID,
+		arg.Username,
+		arg.PasswordHash,
+		arg.CreatedAt,
+	)
+	var i AdminUser
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.PasswordHash,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createBan = `-- name: CreateBan :one
+INSERT INTO bans (id, network, reason, created_by, created_at, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, network, reason, created_by, created_at, expires_at
+`
+
+type CreateBanParams struct {
+	ID        uuid.UUID
+	Network   netip.Prefix
+	Reason    string
+	CreatedBy string
+	CreatedAt time.Time
+	ExpiresAt pgtype.Timestamptz
+}
+
+func (q *Queries) CreateBan(ctx context.Context, arg CreateBanParams) (Ban, error) {
+	row := q.db.QueryRow(ctx, createBan,
+		arg.ID,
+		arg.Network,
+		arg.Reason,
+		arg.CreatedBy,
+		arg.CreatedAt,
+		arg.ExpiresAt,
+	)
+	var i Ban
+	err := row.Scan(
+		&i.ID,
+		&i.Network,
+		&i.Reason,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
+
+const createGuestbook = `-- name: CreateGuestbook :one
+INSERT INTO guestbook (id, slug, title, description, pre_moderation, max_length, open, created_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING id, slug, title, description, pre_moderation, max_length, open, created_at
+`
+
+type CreateGuestbookParams struct {
+	ID            uuid.UUID
+	Slug          string
+	Title         string
+	Description   string
+	PreModeration bool
+	MaxLength     int32
+	Open          bool
+	CreatedAt     time.Time
+}
+
+func (q *Queries) CreateGuestbook(ctx context.Context, arg CreateGuestbookParams) (Guestbook, error) {
+	row := q.db.QueryRow(ctx, createGuestbook,
+		arg.ID,
+		arg.Slug,
+		arg.Title,
+		arg.Description,
+		arg.PreModeration,
+		arg.MaxLength,
+		arg.Open,
+		arg.CreatedAt,
+	)
+	var i Guestbook
+	err := row.Scan(
+		&i.ID,
+		&i.Slug,
+		&i.Title,
+		&i.Description,
+		&i.PreModeration,
+		&i.MaxLength,
+		&i.Open,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createSession = `-- name: CreateSession :exec
+INSERT INTO admin_session (token_hash, admin_id, created_at, expires_at)
+VALUES ($1, $2, $3, $4)
+`
+
+type CreateSessionParams struct {
+	TokenHash []byte
+	AdminID   uuid.UUID
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) error {
+	_, err := q.db.Exec(ctx, createSession,
+		arg.TokenHash,
+		arg.AdminID,
+		arg.CreatedAt,
+		arg.ExpiresAt,
+	)
+	return err
+}
+
+const deleteExpiredSessions = `-- name: DeleteExpiredSessions :exec
+DELETE FROM admin_session
+WHERE expires_at <= now()
+`
+
+func (q *Queries) DeleteExpiredSessions(ctx context.Context) error {
+	_, err := q.db.Exec(ctx, deleteExpiredSessions)
+	return err
+}
+
+const deleteGuest = `-- name: DeleteGuest :execrows
+DELETE FROM guest
+WHERE id = $1
+`
+
+func (q *Queries) DeleteGuest(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteGuest, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteSession = `-- name: DeleteSession :exec
+DELETE FROM admin_session
+WHERE token_hash = $1
+`
+
+func (q *Queries) DeleteSession(ctx context.Context, tokenHash []byte) error {
+	_, err := q.db.Exec(ctx, deleteSession, tokenHash)
+	return err
+}
+
+const editMessage = `-- name: EditMessage :one
+WITH old AS (
+  SELECT id, message
+  FROM guest
+  WHERE id = $1::uuid
+  FOR UPDATE
+), edit AS (
+  INSERT INTO guest_edit (guest_id, message, edited_at)
+  SELECT id, message, $2::timestamptz
+  FROM old
+)
+UPDATE guest
+SET message = $3::text, status = $4::guest_status, updated_at = $2::timestamptz
+FROM old
+WHERE guest.id = old.id
+RETURNING guest.id, guest.message, guest.ip, guest.created_at, guest.updated_at, guest.status, guest.moderated_by, guest.moderated_at, guest.search, guest.parent_id, guest.depth, guest.name, guest.website, guest.edit_token_hash, guest.guestbook_id
+`
+
+type EditMessageParams struct {
+	ID       uuid.UUID
+	EditedAt time.Time
+	Message  string
+	Status   GuestStatus
+}
+
+func (q *Queries) EditMessage(ctx context.Context, arg EditMessageParams) (Guest, error) {
+	row := q.db.QueryRow(ctx, editMessage,
+		arg.ID,
+		arg.EditedAt,
+		arg.Message,
+		arg.Status,
+	)
+	var i Guest
+	err := row.Scan(
+		&i.ID,
+		&i.Message,
+		&i.Ip,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Status,
+		&i.ModeratedBy,
+		&i.ModeratedAt,
+		&i.Search,
+		&i.ParentID,
+		&i.Depth,
+		&i.Name,
+		&i.Website,
+		&i.EditTokenHash,
+		&i.GuestbookID,
+	)
+	return i, err
+}
+
+const findAPIKey = `-- name: FindAPIKey :one
+SELECT id, name, key_hash, created_by, created_at
+FROM api_key
+WHERE key_hash = $1
+`
+
+func (q *Queries) FindAPIKey(ctx context.Context, keyHash []byte) (APIKey, error) {
+	row := q.db.QueryRow(ctx, findAPIKey, keyHash)
+	var i APIKey
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.KeyHash,
+		&i.CreatedBy,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const findActiveBan = `-- name: FindActiveBan :one
+SELECT id, network, reason, created_by, created_at, expires_at
+FROM bans
+WHERE network >>= $1::inet
+  AND (expires_at IS NULL OR expires_at > now())
+ORDER BY masklen(network) DESC
+LIMIT 1
+`
+
+func (q *Queries) FindActiveBan(ctx context.Context, ip net.IP) (Ban, error) {
+	row := q.db.QueryRow(ctx, findActiveBan, ip)
+	var i Ban
+	err := row.Scan(
+		&i.ID,
+		&i.Network,
+		&i.Reason,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
+
+const findAdminByUsername = `-- name: FindAdminByUsername :one
+SELECT id, username, password_hash, created_at
+FROM admin_user
+WHERE username = $1
+`
+
+func (q *Queries) FindAdminByUsername(ctx context.Context, username string) (AdminUser, error) {
+	row := q.db.QueryRow(ctx, findAdminByUsername, username)
+	var i AdminUser
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.PasswordHash,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const findAll = `-- name: FindAll :many
+SELECT id, message, ip, created_at, updated_at, status, moderated_by, moderated_at, search, parent_id, depth, name, website, edit_token_hash, guestbook_id
+FROM guest
+ORDER BY created_at DESC, id DESC
+LIMIT $1
+`
+
+func (q *Queries) FindAll(ctx context.Context, limit int32) ([]Guest, error) {
+	rows, err := q.db.Query(ctx, findAll, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Guest
+	for rows.Next() {
+		var i Guest
+		if err := rows.Scan(
+			&i.ID,
+			&i.Message,
+			&i.Ip,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Status,
+			&i.ModeratedBy,
+			&i.ModeratedAt,
+			&i.Search,
+			&i.ParentID,
+			&i.Depth,
+			&i.Name,
+			&i.Website,
+			&i.EditTokenHash,
+			&i.GuestbookID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const findApproved = `-- name: FindApproved :many
+SELECT id, message, ip, created_at, updated_at, status, moderated_by, moderated_at, search, parent_id, depth, name, website, edit_token_hash, guestbook_id
+FROM guest
+WHERE guestbook_id = $1
+  AND status = 'approved'
+  AND parent_id IS NULL
+ORDER BY created_at DESC, id DESC
+LIMIT $2
+`
+
+type FindApprovedParams struct {
+	GuestbookID uuid.UUID
+	Limit       int32
+}
+
+func (q *Queries) FindApproved(ctx context.Context, arg FindApprovedParams) ([]Guest, error) {
+	rows, err := q.db.Query(ctx, findApproved, arg.GuestbookID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Guest
+	for rows.Next() {
+		var i Guest
+		if err := rows.Scan(
+			&i.ID,
+			&i.Message,
+			&i.Ip,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Status,
+			&i.ModeratedBy,
+			&i.ModeratedAt,
+			&i.Search,
+			&i.ParentID,
+			&i.Depth,
+			&i.Name,
+			&i.Website,
+			&i.EditTokenHash,
+			&i.GuestbookID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const findApprovedAfter = `-- name: FindApprovedAfter :many
+SELECT id, message, ip, created_at, updated_at, status, moderated_by, moderated_at, search, parent_id, depth, name, website, edit_token_hash, guestbook_id
+FROM guest
+WHERE guestbook_id = $1::uuid
+  AND status = 'approved'
+  AND parent_id IS NULL
+  AND (created_at, id) > ($2::timestamptz, $3::uuid)
+ORDER BY created_at ASC, id ASC
+LIMIT $4
+`
+
+type FindApprovedAfterParams struct {
+	GuestbookID uuid.UUID
+	CreatedAt   time.Time
+	ID          uuid.UUID
+	PageSize    int32
+}
+
+func (q *Queries) FindApprovedAfter(ctx context.Context, arg FindApprovedAfterParams) ([]Guest, error) {
+	rows, err := q.db.Query(ctx, findApprovedAfter,
+		arg.GuestbookID,
+		arg.CreatedAt,
+		arg.ID,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Guest
+	for rows.Next() {
+		var i Guest
+		if err := rows.Scan(
+			&i.ID,
+			&i.Message,
+			&i.Ip,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Status,
+			&i.ModeratedBy,
+			&i.ModeratedAt,
+			&i.Search,
+			&i.ParentID,
+			&i.Depth,
+			&i.Name,
+			&i.Website,
+			&i.EditTokenHash,
+			&i.GuestbookID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const findApprovedBefore = `-- name: FindApprovedBefore :many
+SELECT id, message, ip, created_at, updated_at, status, moderated_by, moderated_at, search, parent_id, depth, name, website, edit_token_hash, guestbook_id
+FROM guest
+WHERE guestbook_id = $1::uuid
+  AND status = 'approved'
+  AND parent_id IS NULL
+  AND (created_at, id) < ($2::timestamptz, $3::uuid)
+ORDER BY created_at DESC, id DESC
+LIMIT $4
+`
+
+type FindApprovedBeforeParams struct {
+	GuestbookID uuid.UUID
+	CreatedAt   time.Time
+	ID          uuid.UUID
+	PageSize    int32
+}
+
+func (q *Queries) FindApprovedBefore(ctx context.Context, arg FindApprovedBeforeParams) ([]Guest, error) {
+	rows, err := q.db.Query(ctx, findApprovedBefore,
+		arg.GuestbookID,
+		arg.CreatedAt,
+		arg.ID,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Guest
+	for rows.Next() {
+		var i Guest
+		if err := rows.Scan(
+			&i.ID,
+			&i.Message,
+			&i.Ip,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Status,
+			&i.ModeratedBy,
+			&i.ModeratedAt,
+			&i.Search,
+			&i.ParentID,
+			&i.Depth,
+			&i.Name,
+			&i.Website,
+			&i.EditTokenHash,
+			&i.GuestbookID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const findApprovedSinceID = `-- name: FindApprovedSinceID :many
+SELECT id, message, ip, created_at, updated_at, status, moderated_by, moderated_at, search, parent_id, depth, name, website, edit_token_hash, guestbook_id
+FROM guest
+WHERE status = 'approved'
+  AND id > $1::uuid
+ORDER BY id ASC
+LIMIT $2
+`
+
+type FindApprovedSinceIDParams struct {
+	ID       uuid.UUID
+	PageSize int32
+}
+
+func (q *Queries) FindApprovedSinceID(ctx context.Context, arg FindApprovedSinceIDParams) ([]Guest, error) {
+	rows, err := q.db.Query(ctx, findApprovedSinceID, arg.ID, arg.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Guest
+	for rows.Next() {
+		var i Guest
+		if err := rows.Scan(
+			&i.ID,
+			&i.Message,
+			&i.Ip,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Status,
+			&i.ModeratedBy,
+			&i.ModeratedAt,
+			&i.Search,
+			&i.ParentID,
+			&i.Depth,
+			&i.Name,
+			&i.Website,
+			&i.EditTokenHash,
+			&i.GuestbookID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const findByID = `-- name: FindByID :one
+SELECT id, message, ip, created_at, updated_at, status, moderated_by, moderated_at, search, parent_id, depth, name, website, edit_token_hash, guestbook_id
+FROM guest
+WHERE id = $1
+`
+
+func (q *Queries) FindByID(ctx context.Context, id uuid.UUID) (Guest, error) {
+	row := q.db.QueryRow(ctx, findByID, id)
+	var i Guest
+	err := row.Scan(
+		&i.ID,
+		&i.Message,
+		&i.Ip,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Status,
+		&i.ModeratedBy,
+		&i.ModeratedAt,
+		&i.Search,
+		&i.ParentID,
+		&i.Depth,
+		&i.Name,
+		&i.Website,
+		&i.EditTokenHash,
+		&i.GuestbookID,
+	)
+	return i, err
+}
+
+const findGuestbookApprovedSinceID = `-- name: FindGuestbookApprovedSinceID :many
+SELECT id, message, ip, created_at, updated_at, status, moderated_by, moderated_at, search, parent_id, depth, name, website, edit_token_hash, guestbook_id
+FROM guest
+WHERE guestbook_id = (SELECT id FROM guestbook WHERE slug = $1::text)
+  AND status = 'approved'
+  AND id > $2::uuid
+ORDER BY id ASC
+LIMIT $3
+`
+
+type FindGuestbookApprovedSinceIDParams struct {
+	Slug     string
+	ID       uuid.UUID
+	PageSize int32
+}
+
+func (q *Queries) FindGuestbookApprovedSinceID(ctx context.Context, arg FindGuestbookApprovedSinceIDParams) ([]Guest, error) {
+	rows, err := q.db.Query(ctx, findGuestbookApprovedSinceID, arg.Slug, arg.ID, arg.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Guest
+	for rows.Next() {
+		var i Guest
+		if err := rows.Scan(
+			&i.ID,
+			&i.Message,
+			&i.Ip,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Status,
+			&i.ModeratedBy,
+			&i.ModeratedAt,
+			&i.Search,
+			&i.ParentID,
+			&i.Depth,
+			&i.Name,
+			&i.Website,
+			&i.EditTokenHash,
+			&i.GuestbookID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const findGuestbookByID = `-- name: FindGuestbookByID :one
+SELECT id, slug, title, description, pre_moderation, max_length, open, created_at
+FROM guestbook
+WHERE id = $1
+`
+
+func (q *Queries) FindGuestbookByID(ctx context.Context, id uuid.UUID) (Guestbook, error) {
+	row := q.db.QueryRow(ctx, findGuestbookByID, id)
+	var i Guestbook
+	err := row.Scan(
+		&i.ID,
+		&i.Slug,
+		&i.Title,
+		&i.Description,
+		&i.PreModeration,
+		&i.MaxLength,
+		&i.Open,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const findGuestbookBySlug = `-- name: FindGuestbookBySlug :one
+SELECT id, slug, title, description, pre_moderation, max_length, open, created_at
+FROM guestbook
+WHERE slug = $1
+`
+
+func (q *Queries) FindGuestbookBySlug(ctx context.Context, slug string) (Guestbook, error) {
+	row := q.db.QueryRow(ctx, findGuestbookBySlug, slug)
+	var i Guestbook
+	err := row.Scan(
+		&i.ID,
+		&i.Slug,
+		&i.Title,
+		&i.Description,
+		&i.PreModeration,
+		&i.MaxLength,
+		&i.Open,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const findPage = `-- name: FindPage :many
+SELECT id, message, ip, created_at, updated_at, status, moderated_by, moderated_at, search, parent_id, depth, name, website, edit_token_hash, guestbook_id
+FROM guest
+ORDER BY created_at DESC, id DESC
+LIMIT $1 OFFSET $2
+`
+
+type FindPageParams struct {
+	Limit  int32
+	Offset int32
+}
+
+func (q *Queries) FindPage(ctx context.Context, arg FindPageParams) ([]Guest, error) {
+	rows, err := q.db.Query(ctx, findPage, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Guest
+	for rows.Next() {
+		var i Guest
+		if err := rows.Scan(
+			&i.ID,
+			&i.Message,
+			&i.Ip,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Status,
+			&i.ModeratedBy,
+			&i.ModeratedAt,
+			&i.Search,
+			&i.ParentID,
+			&i.Depth,
+			&i.Name,
+			&i.Website,
+			&i.EditTokenHash,
+			&i.GuestbookID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const findPending = `-- name: FindPending :many
+SELECT id, message, ip, created_at, updated_at, status, moderated_by, moderated_at, search, parent_id, depth, name, website, edit_token_hash, guestbook_id
 FROM guest
-ORDER BY created_at DESC
+WHERE status = 'pending'
+ORDER BY created_at ASC, id ASC
 LIMIT $1
 `
 
-func (q *Queries) FindAll(ctx context.Context, limit int32) ([]Guest, error) {
-	rows, err := q.db.Query(ctx, findAll, limit)
+func (q *Queries) FindPending(ctx context.Context, limit int32) ([]Guest, error) {
+	rows, err := q.db.Query(ctx, findPending, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Guest
+	for rows.Next() {
+		var i Guest
+		if err := rows.Scan(
+			&i.ID,
+			&i.Message,
+			&i.Ip,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Status,
+			&i.ModeratedBy,
+			&i.ModeratedAt,
+			&i.Search,
+			&i.ParentID,
+			&i.Depth,
+			&i.Name,
+			&i.Website,
+			&i.EditTokenHash,
+			&i.GuestbookID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const findSession = `-- name: FindSession :one
+SELECT admin_user.id, admin_user.username, admin_session.expires_at
+FROM admin_session
+JOIN admin_user ON admin_user.id = admin_session.admin_id
+WHERE admin_session.token_hash = $1
+  AND admin_session.expires_at > now()
+`
+
+type FindSessionRow struct {
+	ID        uuid.UUID
+	Username  string
+	ExpiresAt time.Time
+}
+
+func (q *Queries) FindSession(ctx context.Context, tokenHash []byte) (FindSessionRow, error) {
+	row := q.db.QueryRow(ctx, findSession, tokenHash)
+	var i FindSessionRow
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
+
+const findThread = `-- name: FindThread :many
+WITH RECURSIVE thread AS (
+  SELECT id, message, ip, created_at, updated_at, status, moderated_by, moderated_at, search, parent_id, depth, name, website, edit_token_hash, guestbook_id
+  FROM guest
+  WHERE id = $1::uuid
+    AND status = 'approved'
+  UNION ALL
+  SELECT g.id, g.message, g.ip, g.created_at, g.updated_at, g.status, g.moderated_by, g.moderated_at, g.search, g.parent_id, g.depth, g.name, g.website, g.edit_token_hash, g.guestbook_id
+  FROM guest g
+  JOIN thread t ON g.parent_id = t.id
+  WHERE g.status = 'approved'
+)
+SELECT id, message, ip, created_at, updated_at, status, moderated_by, moderated_at, search, parent_id, depth, name, website, edit_token_hash, guestbook_id
+FROM thread
+ORDER BY depth ASC, created_at ASC, id ASC
+LIMIT $2
+`
+
+type FindThreadParams struct {
+	ID       uuid.UUID
+	PageSize int32
+}
+
+func (q *Queries) FindThread(ctx context.Context, arg FindThreadParams) ([]Guest, error) {
+	rows, err := q.db.Query(ctx, findThread, arg.ID, arg.PageSize)
 	if err != nil {
 		return nil, err
 	}
@@ -46,6 +1020,16 @@ func (q *Queries) FindAll(ctx context.Context, limit int32) ([]Guest, error) {
 			&i.Ip,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Status,
+			&i.ModeratedBy,
+			&i.ModeratedAt,
+			&i.Search,
+			&i.ParentID,
+			&i.Depth,
+			&i.Name,
+			&i.Website,
+			&i.EditTokenHash,
+			&i.GuestbookID,
 		); err != nil {
 			return nil, err
 		}
@@ -58,16 +1042,23 @@ func (q *Queries) FindAll(ctx context.Context, limit int32) ([]Guest, error) {
 }
 
 const insert = `-- name: Insert :one
-INSERT INTO guest (id, message, created_at, updated_at, ip)
-VALUES ($1, $2, $3, $3, $4)
-RETURNING id, message, ip, created_at, updated_at
+INSERT INTO guest (id, message, created_at, updated_at, ip, status, parent_id, depth, name, website, edit_token_hash, guestbook_id)
+VALUES ($1, $2, $3, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+RETURNING id, message, ip, created_at, updated_at, status, moderated_by, moderated_at, search, parent_id, depth, name, website, edit_token_hash, guestbook_id
 `
 
 type InsertParams struct {
-	ID        uuid.UUID
-	Message   string
-	CreatedAt time.Time
-	Ip        net.IP
+	ID            uuid.UUID
+	Message       string
+	CreatedAt     time.Time
+	Ip            net.IP
+	Status        GuestStatus
+	ParentID      pgtype.UUID
+	Depth         int32
+	Name          string
+	Website       string
+	EditTokenHash []byte
+	GuestbookID   uuid.UUID
 }
 
 func (q *Queries) Insert(ctx context.Context, arg InsertParams) (Guest, error) {
@@ -76,6 +1067,317 @@ func (q *Queries) Insert(ctx context.Context, arg InsertParams) (Guest, error) {
 		arg.Message,
 		arg.CreatedAt,
 		arg.Ip,
+		arg.Status,
+		arg.ParentID,
+		arg.Depth,
+		arg.Name,
+		arg.Website,
+		arg.EditTokenHash,
+		arg.GuestbookID,
+	)
+	var i Guest
+	err := row.Scan(
+		&i.ID,
+		&i.Message,
+		&i.Ip,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Status,
+		&i.ModeratedBy,
+		&i.ModeratedAt,
+		&i.Search,
+		&i.ParentID,
+		&i.Depth,
+		&i.Name,
+		&i.Website,
+		&i.EditTokenHash,
+		&i.GuestbookID,
+	)
+	return i, err
+}
+
+const liftBan = `-- name: LiftBan :execrows
+DELETE FROM bans
+WHERE id = $1
+`
+
+func (q *Queries) LiftBan(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, liftBan, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const listAPIKeys = `-- name: ListAPIKeys :many
+SELECT id, name, key_hash, created_by, created_at
+FROM api_key
+ORDER BY created_at DESC
+`
+
+func (q *Queries) ListAPIKeys(ctx context.Context) ([]APIKey, error) {
+	rows, err := q.db.Query(ctx, listAPIKeys)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []APIKey
+	for rows.Next() {
+		var i APIKey
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.KeyHash,
+			&i.CreatedBy,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listActiveBans = `-- name: ListActiveBans :many
+SELECT id, network, reason, created_by, created_at, expires_at
+FROM bans
+WHERE expires_at IS NULL OR expires_at > now()
+ORDER BY created_at DESC
+`
+
+func (q *Queries) ListActiveBans(ctx context.Context) ([]Ban, error) {
+	rows, err := q.db.Query(ctx, listActiveBans)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Ban
+	for rows.Next() {
+		var i Ban
+		if err := rows.Scan(
+			&i.ID,
+			&i.Network,
+			&i.Reason,
+			&i.CreatedBy,
+			&i.CreatedAt,
+			&i.ExpiresAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listGuestbooks = `-- name: ListGuestbooks :many
+SELECT id, slug, title, description, pre_moderation, max_length, open, created_at
+FROM guestbook
+ORDER BY created_at ASC
+`
+
+func (q *Queries) ListGuestbooks(ctx context.Context) ([]Guestbook, error) {
+	rows, err := q.db.Query(ctx, listGuestbooks)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Guestbook
+	for rows.Next() {
+		var i Guestbook
+		if err := rows.Scan(
+			&i.ID,
+			&i.Slug,
+			&i.Title,
+			&i.Description,
+			&i.PreModeration,
+			&i.MaxLength,
+			&i.Open,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const revokeAPIKey = `-- name: RevokeAPIKey :execrows
+DELETE FROM api_key
+WHERE id = $1
+`
+
+func (q *Queries) RevokeAPIKey(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, revokeAPIKey, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const searchApproved = `-- name: SearchApproved :many
+SELECT g.id, g.message, g.ip, g.created_at, g.updated_at, g.parent_id, g.name, g.website,
+  ts_headline('english', g.message, q, E'StartSel=\uE000, StopSel=\uE001, HighlightAll=true')::text AS headline,
+  ts_rank(g.search, q)::real AS rank
+FROM guest g, websearch_to_tsquery('english', $1::text) q
+WHERE g.guestbook_id = $2::uuid
+  AND g.status = 'approved'
+  AND g.search @@ q
+ORDER BY rank DESC, g.created_at DESC, g.id DESC
+LIMIT $3
+`
+
+type SearchApprovedParams struct {
+	Query       string
+	GuestbookID uuid.UUID
+	PageSize    int32
+}
+
+type SearchApprovedRow struct {
+	ID        uuid.UUID
+	Message   string
+	Ip        net.IP
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	ParentID  pgtype.UUID
+	Name      string
+	Website   string
+	Headline  string
+	Rank      float32
+}
+
+func (q *Queries) SearchApproved(ctx context.Context, arg SearchApprovedParams) ([]SearchApprovedRow, error) {
+	rows, err := q.db.Query(ctx, searchApproved, arg.Query, arg.GuestbookID, arg.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchApprovedRow
+	for rows.Next() {
+		var i SearchApprovedRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Message,
+			&i.Ip,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.ParentID,
+			&i.Name,
+			&i.Website,
+			&i.Headline,
+			&i.Rank,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchApprovedBefore = `-- name: SearchApprovedBefore :many
+SELECT g.id, g.message, g.ip, g.created_at, g.updated_at, g.parent_id, g.name, g.website,
+  ts_headline('english', g.message, q, E'StartSel=\uE000, StopSel=\uE001, HighlightAll=true')::text AS headline,
+  ts_rank(g.search, q)::real AS rank
+FROM guest g, websearch_to_tsquery('english', $1::text) q
+WHERE g.guestbook_id = $2::uuid
+  AND g.status = 'approved'
+  AND g.search @@ q
+  AND (ts_rank(g.search, q), g.created_at, g.id) < ($3::real, $4::timestamptz, $5::uuid)
+ORDER BY rank DESC, g.created_at DESC, g.id DESC
+LIMIT $6
+`
+
+type SearchApprovedBeforeParams struct {
+	Query       string
+	GuestbookID uuid.UUID
+	Rank        float32
+	CreatedAt   time.Time
+	ID          uuid.UUID
+	PageSize    int32
+}
+
+type SearchApprovedBeforeRow struct {
+	ID        uuid.UUID
+	Message   string
+	Ip        net.IP
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	ParentID  pgtype.UUID
+	Name      string
+	Website   string
+	Headline  string
+	Rank      float32
+}
+
+func (q *Queries) SearchApprovedBefore(ctx context.Context, arg SearchApprovedBeforeParams) ([]SearchApprovedBeforeRow, error) {
+	rows, err := q.db.Query(ctx, searchApprovedBefore,
+		arg.Query,
+		arg.GuestbookID,
+		arg.Rank,
+		arg.CreatedAt,
+		arg.ID,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchApprovedBeforeRow
+	for rows.Next() {
+		var i SearchApprovedBeforeRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Message,
+			&i.Ip,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.ParentID,
+			&i.Name,
+			&i.Website,
+			&i.Headline,
+			&i.Rank,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setStatus = `-- name: SetStatus :one
+UPDATE guest
+SET status = $2, moderated_by = $3, moderated_at = $4
+WHERE id = $1
+RETURNING id, message, ip, created_at, updated_at, status, moderated_by, moderated_at, search, parent_id, depth, name, website, edit_token_hash, guestbook_id
+`
+
+type SetStatusParams struct {
+	ID          uuid.UUID
+	Status      GuestStatus
+	ModeratedBy pgtype.Text
+	ModeratedAt pgtype.Timestamptz
+}
+
+func (q *Queries) SetStatus(ctx context.Context, arg SetStatusParams) (Guest, error) {
+	row := q.db.QueryRow(ctx, setStatus,
+		arg.ID,
+		arg.Status,
+		arg.ModeratedBy,
+		arg.ModeratedAt,
 	)
 	var i Guest
 	err := row.Scan(
@@ -84,6 +1386,93 @@ func (q *Queries) Insert(ctx context.Context, arg InsertParams) (Guest, error) {
 		&i.Ip,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Status,
+		&i.ModeratedBy,
+		&i.ModeratedAt,
+		&i.Search,
+		&i.ParentID,
+		&i.Depth,
+		&i.Name,
+		&i.Website,
+		&i.EditTokenHash,
+		&i.GuestbookID,
+	)
+	return i, err
+}
+
+const stats = `-- name: Stats :one
+SELECT
+  COUNT(*) AS total,
+  COUNT(*) FILTER (WHERE status = 'approved') AS approved,
+  COUNT(*) FILTER (WHERE status = 'pending') AS pending,
+  COUNT(*) FILTER (WHERE status = 'rejected') AS rejected,
+  COUNT(*) FILTER (WHERE created_at > now() - interval '1 day') AS last_day,
+  (
+    SELECT COALESCE(SUM(count), 0)::bigint
+    FROM discarded_post
+    WHERE day = CURRENT_DATE
+  ) AS discarded_today
+FROM guest
+`
+
+type StatsRow struct {
+	Total          int64
+	Approved       int64
+	Pending        int64
+	Rejected       int64
+	LastDay        int64
+	DiscardedToday int64
+}
+
+func (q *Queries) Stats(ctx context.Context) (StatsRow, error) {
+	row := q.db.QueryRow(ctx, stats)
+	var i StatsRow
+	err := row.Scan(
+		&i.Total,
+		&i.Approved,
+		&i.Pending,
+		&i.Rejected,
+		&i.LastDay,
+		&i.DiscardedToday,
+	)
+	return i, err
+}
+
+const updateGuestbook = `-- name: UpdateGuestbook :one
+UPDATE guestbook
+SET title = $2, description = $3, pre_moderation = $4, max_length = $5, open = $6
+WHERE id = $1
+RETURNING id, slug, title, description, pre_moderation, max_length, open, created_at
+`
+
+type UpdateGuestbookParams struct {
+	ID            uuid.UUID
+	Title         string
+	Description   string
+	PreModeration bool
+	MaxLength     int32
+	Open          bool
+}
+
+func (q *Queries) UpdateGuestbook(ctx context.Context, arg UpdateGuestbookParams) (Guestbook, error) {
+	row := q.db.QueryRow(ctx, updateGuestbook,
+		arg.ID,
+		arg.Title,
+		arg.Description,
+		arg.PreModeration,
+		arg.MaxLength,
+		arg.Open,
+	)
+	var i Guestbook
+	err := row.Scan(
+		&i.ID,
+		&i.Slug,
+		&i.Title,
+		&i.Description,
+		&i.PreModeration,
+		&i.MaxLength,
+		&i.Open,
+		&i.CreatedAt,
 	)
 	return i, err
 }
@@ -10,12 +10,13 @@ import (
 	"github.com/joho/godotenv"
 
 	"github.com/dreamsofcode-io/guestbook/internal/app"
+	"github.com/dreamsofcode-io/guestbook/internal/cli"
 )
 
 //go:embed migrations/*.sql
 var migrations embed.FS
 
-//go:embed templates/*.html
+//go:embed templates/*.html templates/admin/*.html
 var templates embed.FS
 
 func main() {
@@ -25,6 +26,16 @@ func main() {
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
 	defer cancel()
 
+	if len(os.Args) > 1 {
+		err := cli.Run(ctx, logger, migrations, os.Stdout, os.Args[1:])
+		if err != nil {
+			logger.Error("failed to run command", slog.Any("error", err))
+			os.Exit(1)
+		}
+
+		return
+	}
+
 	a := app.New(logger, migrations, templates)
 
 	if err := a.Start(ctx); err != nil {
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS guest_created_at_id_idx;
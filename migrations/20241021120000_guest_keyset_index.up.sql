@@ -0,0 +1 @@
+CREATE INDEX guest_created_at_id_idx ON guest (created_at, id);
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS guest_status_created_at_id_idx;
+
+ALTER TABLE guest
+  DROP COLUMN moderated_at,
+  DROP COLUMN moderated_by,
+  DROP COLUMN status;
+
+DROP TYPE guest_status;
@@ -0,0 +1,8 @@
+CREATE TYPE guest_status AS ENUM ('pending', 'approved', 'rejected');
+
+ALTER TABLE guest
+  ADD COLUMN status guest_status not null default 'approved',
+  ADD COLUMN moderated_by text,
+  ADD COLUMN moderated_at timestamptz;
+
+CREATE INDEX guest_status_created_at_id_idx ON guest (status, created_at, id);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS admin_session;
+DROP TABLE IF EXISTS admin_user;
@@ -0,0 +1,15 @@
+CREATE TABLE admin_user (
+  id uuid primary key,
+  username text not null unique,
+  password_hash text not null,
+  created_at timestamptz not null
+);
+
+CREATE TABLE admin_session (
+  token_hash bytea primary key,
+  admin_id uuid not null references admin_user (id) on delete cascade,
+  created_at timestamptz not null,
+  expires_at timestamptz not null
+);
+
+CREATE INDEX ON admin_session (expires_at);
@@ -0,0 +1,10 @@
+CREATE TABLE bans (
+  id uuid primary key,
+  network cidr not null,
+  reason text not null,
+  created_by text not null,
+  created_at timestamptz not null,
+  expires_at timestamptz
+);
+
+CREATE INDEX bans_network_idx ON bans USING gist (network inet_ops);
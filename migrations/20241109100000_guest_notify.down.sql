@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS guest_published ON guest;
+
+DROP FUNCTION IF EXISTS notify_guest_published();
@@ -0,0 +1,13 @@
+CREATE FUNCTION notify_guest_published() RETURNS trigger AS $$
+BEGIN
+  IF NEW.status = 'approved' AND (TG_OP = 'INSERT' OR OLD.status <> 'approved') THEN
+    PERFORM pg_notify('guest_published', NEW.id::text);
+  END IF;
+
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER guest_published
+  AFTER INSERT OR UPDATE OF status ON guest
+  FOR EACH ROW EXECUTE FUNCTION notify_guest_published();
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS guest_search_idx;
+
+ALTER TABLE guest DROP COLUMN IF EXISTS search;
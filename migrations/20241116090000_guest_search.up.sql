@@ -0,0 +1,4 @@
+ALTER TABLE guest
+  ADD COLUMN search tsvector GENERATED ALWAYS AS (to_tsvector('english', message)) STORED;
+
+CREATE INDEX guest_search_idx ON guest USING GIN (search);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS reaction;
+
+DROP TYPE IF EXISTS reaction_kind;
@@ -0,0 +1,9 @@
+CREATE TYPE reaction_kind AS ENUM ('like', 'heart', 'laugh', 'wow', 'party');
+
+CREATE TABLE reaction (
+  guest_id uuid not null references guest (id) on delete cascade,
+  kind reaction_kind not null,
+  visitor bytea not null,
+  created_at timestamptz not null,
+  primary key (guest_id, kind, visitor)
+);
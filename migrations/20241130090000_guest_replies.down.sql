@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS guest_parent_id_created_at_id_idx;
+
+ALTER TABLE guest
+  DROP COLUMN IF EXISTS depth,
+  DROP COLUMN IF EXISTS parent_id;
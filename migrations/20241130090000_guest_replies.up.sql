@@ -0,0 +1,5 @@
+ALTER TABLE guest
+  ADD COLUMN parent_id uuid references guest (id) on delete cascade,
+  ADD COLUMN depth integer not null default 0;
+
+CREATE INDEX guest_parent_id_created_at_id_idx ON guest (parent_id, created_at, id);
@@ -0,0 +1,3 @@
+ALTER TABLE guest
+  DROP COLUMN IF EXISTS website,
+  DROP COLUMN IF EXISTS name;
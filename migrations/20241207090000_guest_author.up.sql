@@ -0,0 +1,3 @@
+ALTER TABLE guest
+  ADD COLUMN name text not null default '',
+  ADD COLUMN website text not null default '';
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS guest_edit;
+
+ALTER TABLE guest
+  DROP COLUMN IF EXISTS edit_token_hash;
@@ -0,0 +1,11 @@
+ALTER TABLE guest
+  ADD COLUMN edit_token_hash bytea;
+
+CREATE TABLE guest_edit (
+  id bigint generated always as identity primary key,
+  guest_id uuid not null references guest (id) on delete cascade,
+  message text not null,
+  edited_at timestamptz not null
+);
+
+CREATE INDEX guest_edit_guest_id_idx ON guest_edit (guest_id, edited_at);
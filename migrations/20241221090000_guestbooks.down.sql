@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS guest_guestbook_id_created_at_id_idx;
+
+ALTER TABLE guest
+  DROP COLUMN IF EXISTS guestbook_id;
+
+DROP TABLE IF EXISTS guestbook;
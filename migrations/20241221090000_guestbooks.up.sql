@@ -0,0 +1,25 @@
+CREATE TABLE guestbook (
+  id uuid primary key,
+  slug text not null unique,
+  title text not null,
+  description text not null default '',
+  pre_moderation boolean not null default false,
+  max_length integer not null default 0,
+  open boolean not null default true,
+  created_at timestamptz not null
+);
+
+-- Existing entries belong to the default book, which is served at /.
+INSERT INTO guestbook (id, slug, title, created_at)
+VALUES (gen_random_uuid(), 'default', 'Guest Book', now());
+
+ALTER TABLE guest
+  ADD COLUMN guestbook_id uuid references guestbook (id) on delete cascade;
+
+UPDATE guest
+SET guestbook_id = (SELECT id FROM guestbook WHERE slug = 'default');
+
+ALTER TABLE guest
+  ALTER COLUMN guestbook_id SET NOT NULL;
+
+CREATE INDEX guest_guestbook_id_created_at_id_idx ON guest (guestbook_id, created_at, id);
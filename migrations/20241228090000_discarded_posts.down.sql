@@ -0,0 +1 @@
+DROP TABLE IF EXISTS discarded_post;
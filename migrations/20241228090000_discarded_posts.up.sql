@@ -0,0 +1,6 @@
+CREATE TABLE discarded_post (
+  day date not null,
+  reason text not null,
+  count bigint not null default 0,
+  primary key (day, reason)
+);
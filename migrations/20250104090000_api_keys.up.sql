@@ -0,0 +1,7 @@
+CREATE TABLE api_key (
+  id uuid primary key,
+  name text not null,
+  key_hash bytea not null unique,
+  created_by text not null,
+  created_at timestamptz not null
+);
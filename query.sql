@@ -1,13 +1,290 @@
 -- name: Insert :one
-INSERT INTO guest (id, message, created_at, updated_at, ip)
-VALUES ($1, $2, $3, $3, $4)
+INSERT INTO guest (id, message, created_at, updated_at, ip, status, parent_id, depth, name, website, edit_token_hash, guestbook_id)
+VALUES ($1, $2, $3, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 RETURNING *;
 
 -- name: FindAll :many
 SELECT *
 FROM guest
-ORDER BY created_at DESC
+ORDER BY created_at DESC, id DESC
 LIMIT $1;
 
+-- name: FindApproved :many
+SELECT *
+FROM guest
+WHERE guestbook_id = $1
+  AND status = 'approved'
+  AND parent_id IS NULL
+ORDER BY created_at DESC, id DESC
+LIMIT $2;
+
+-- name: FindApprovedBefore :many
+SELECT *
+FROM guest
+WHERE guestbook_id = @guestbook_id::uuid
+  AND status = 'approved'
+  AND parent_id IS NULL
+  AND (created_at, id) < (@created_at::timestamptz, @id::uuid)
+ORDER BY created_at DESC, id DESC
+LIMIT @page_size;
+
+-- name: FindApprovedAfter :many
+SELECT *
+FROM guest
+WHERE guestbook_id = @guestbook_id::uuid
+  AND status = 'approved'
+  AND parent_id IS NULL
+  AND (created_at, id) > (@created_at::timestamptz, @id::uuid)
+ORDER BY created_at ASC, id ASC
+LIMIT @page_size;
+
+-- name: FindApprovedSinceID :many
+SELECT *
+FROM guest
+WHERE status = 'approved'
+  AND id > @id::uuid
+ORDER BY id ASC
+LIMIT @page_size;
+
+-- name: FindGuestbookApprovedSinceID :many
+SELECT *
+FROM guest
+WHERE guestbook_id = (SELECT id FROM guestbook WHERE slug = @slug::text)
+  AND status = 'approved'
+  AND id > @id::uuid
+ORDER BY id ASC
+LIMIT @page_size;
+
+-- name: FindByID :one
+SELECT *
+FROM guest
+WHERE id = $1;
+
+-- name: FindThread :many
+WITH RECURSIVE thread AS (
+  SELECT *
+  FROM guest
+  WHERE id = @id::uuid
+    AND status = 'approved'
+  UNION ALL
+  SELECT g.*
+  FROM guest g
+  JOIN thread t ON g.parent_id = t.id
+  WHERE g.status = 'approved'
+)
+SELECT *
+FROM thread
+ORDER BY depth ASC, created_at ASC, id ASC
+LIMIT @page_size;
+
+-- name: CountReplies :many
+SELECT parent_id::uuid AS parent_id, COUNT(*) AS count
+FROM guest
+WHERE parent_id = ANY(@ids::uuid[])
+  AND status = 'approved'
+GROUP BY parent_id;
+
+-- name: FindPending :many
+SELECT *
+FROM guest
+WHERE status = 'pending'
+ORDER BY created_at ASC, id ASC
+LIMIT $1;
+
+-- name: SetStatus :one
+UPDATE guest
+SET status = $2, moderated_by = $3, moderated_at = $4
+WHERE id = $1
+RETURNING *;
+
 -- name: Count :one
 SELECT COUNT(*) FROM guest;
+
+-- name: CountApproved :one
+SELECT COUNT(*) FROM guest
+WHERE guestbook_id = $1
+  AND parent_id IS NULL
+  AND status = 'approved';
+
+-- name: SearchApproved :many
+SELECT g.id, g.message, g.ip, g.created_at, g.updated_at, g.parent_id, g.name, g.website,
+  ts_headline('english', g.message, q, E'StartSel=\uE000, StopSel=\uE001, HighlightAll=true')::text AS headline,
+  ts_rank(g.search, q)::real AS rank
+FROM guest g, websearch_to_tsquery('english', @query::text) q
+WHERE g.guestbook_id = @guestbook_id::uuid
+  AND g.status = 'approved'
+  AND g.search @@ q
+ORDER BY rank DESC, g.created_at DESC, g.id DESC
+LIMIT @page_size;
+
+-- name: SearchApprovedBefore :many
+SELECT g.id, g.message, g.ip, g.created_at, g.updated_at, g.parent_id, g.name, g.website,
+  ts_headline('english', g.message, q, E'StartSel=\uE000, StopSel=\uE001, HighlightAll=true')::text AS headline,
+  ts_rank(g.search, q)::real AS rank
+FROM guest g, websearch_to_tsquery('english', @query::text) q
+WHERE g.guestbook_id = @guestbook_id::uuid
+  AND g.status = 'approved'
+  AND g.search @@ q
+  AND (ts_rank(g.search, q), g.created_at, g.id) < (@rank::real, @created_at::timestamptz, @id::uuid)
+ORDER BY rank DESC, g.created_at DESC, g.id DESC
+LIMIT @page_size;
+
+-- name: CountSearch :one
+SELECT COUNT(*) FROM guest
+WHERE guestbook_id = @guestbook_id::uuid
+  AND status = 'approved'
+  AND search @@ websearch_to_tsquery('english', @query::text);
+
+-- name: FindPage :many
+SELECT *
+FROM guest
+ORDER BY created_at DESC, id DESC
+LIMIT $1 OFFSET $2;
+
+-- name: EditMessage :one
+WITH old AS (
+  SELECT id, message
+  FROM guest
+  WHERE id = @id::uuid
+  FOR UPDATE
+), edit AS (
+  INSERT INTO guest_edit (guest_id, message, edited_at)
+  SELECT id, message, @edited_at::timestamptz
+  FROM old
+)
+UPDATE guest
+SET message = @message::text, status = @status::guest_status, updated_at = @edited_at::timestamptz
+FROM old
+WHERE guest.id = old.id
+RETURNING guest.*;
+
+-- name: DeleteGuest :execrows
+DELETE FROM guest
+WHERE id = $1;
+
+-- name: Stats :one
+SELECT
+  COUNT(*) AS total,
+  COUNT(*) FILTER (WHERE status = 'approved') AS approved,
+  COUNT(*) FILTER (WHERE status = 'pending') AS pending,
+  COUNT(*) FILTER (WHERE status = 'rejected') AS rejected,
+  COUNT(*) FILTER (WHERE created_at > now() - interval '1 day') AS last_day,
+  (
+    SELECT COALESCE(SUM(count), 0)::bigint
+    FROM discarded_post
+    WHERE day = CURRENT_DATE
+  ) AS discarded_today
+FROM guest;
+
+-- name: CountDiscarded :exec
+INSERT INTO discarded_post (day, reason, count)
+VALUES (CURRENT_DATE, $1, 1)
+ON CONFLICT (day, reason) DO UPDATE SET count = discarded_post.count + 1;
+
+-- name: CreateAdmin :one
+INSERT INTO admin_user (id, username, password_hash, created_at)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: FindAdminByUsername :one
+SELECT *
+FROM admin_user
+WHERE username = $1;
+
+-- name: CreateSession :exec
+INSERT INTO admin_session (token_hash, admin_id, created_at, expires_at)
+VALUES ($1, $2, $3, $4);
+
+-- name: FindSession :one
+SELECT admin_user.id, admin_user.username, admin_session.expires_at
+FROM admin_session
+JOIN admin_user ON admin_user.id = admin_session.admin_id
+WHERE admin_session.token_hash = $1
+  AND admin_session.expires_at > now();
+
+-- name: DeleteSession :exec
+DELETE FROM admin_session
+WHERE token_hash = $1;
+
+-- name: DeleteExpiredSessions :exec
+DELETE FROM admin_session
+WHERE expires_at <= now();
+
+-- name: CreateBan :one
+INSERT INTO bans (id, network, reason, created_by, created_at, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: FindActiveBan :one
+SELECT *
+FROM bans
+WHERE network >>= @ip::inet
+  AND (expires_at IS NULL OR expires_at > now())
+ORDER BY masklen(network) DESC
+LIMIT 1;
+
+-- name: ListActiveBans :many
+SELECT *
+FROM bans
+WHERE expires_at IS NULL OR expires_at > now()
+ORDER BY created_at DESC;
+
+-- name: LiftBan :execrows
+DELETE FROM bans
+WHERE id = $1;
+
+-- name: CreateAPIKey :one
+INSERT INTO api_key (id, name, key_hash, created_by, created_at)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: FindAPIKey :one
+SELECT *
+FROM api_key
+WHERE key_hash = $1;
+
+-- name: ListAPIKeys :many
+SELECT *
+FROM api_key
+ORDER BY created_at DESC;
+
+-- name: RevokeAPIKey :execrows
+DELETE FROM api_key
+WHERE id = $1;
+
+-- name: AddReaction :execrows
+INSERT INTO reaction (guest_id, kind, visitor, created_at)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT DO NOTHING;
+
+-- name: CountReactions :many
+SELECT guest_id, kind, COUNT(*) AS count
+FROM reaction
+WHERE guest_id = ANY(@ids::uuid[])
+GROUP BY guest_id, kind;
+
+-- name: CreateGuestbook :one
+INSERT INTO guestbook (id, slug, title, description, pre_moderation, max_length, open, created_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING *;
+
+-- name: FindGuestbookBySlug :one
+SELECT *
+FROM guestbook
+WHERE slug = $1;
+
+-- name: FindGuestbookByID :one
+SELECT *
+FROM guestbook
+WHERE id = $1;
+
+-- name: ListGuestbooks :many
+SELECT *
+FROM guestbook
+ORDER BY created_at ASC;
+
+-- name: UpdateGuestbook :one
+UPDATE guestbook
+SET title = $2, description = $3, pre_moderation = $4, max_length = $5, open = $6
+WHERE id = $1
+RETURNING *;
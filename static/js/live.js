@@ -0,0 +1,99 @@
+// Prepends new entries to the first page as they are posted. Without
+// JavaScript or EventSource the page still works, it just needs a reload.
+(function () {
+  if (!window.EventSource) {
+    return;
+  }
+
+  var guests = document.getElementById("guests");
+  if (guests && !guests.hasAttribute("data-live")) {
+    return;
+  }
+
+  var url = "/events?book=" + encodeURIComponent(document.body.dataset.book || "");
+  var newest = guests && guests.querySelector("tr[data-id]");
+  if (newest) {
+    url += "&last_event_id=" + encodeURIComponent(newest.dataset.id);
+  }
+
+  var total = document.getElementById("total");
+  var reactions = document.getElementById("reactions");
+  var source = new EventSource(url);
+
+  source.addEventListener("guest", function (event) {
+    var guest = JSON.parse(event.data);
+
+    // Replies only appear in their thread, and like the total only counts
+    // the entries on the feed
+    if (guest.parent_id) {
+      return;
+    }
+
+    // There is no table to add to until the first entry exists
+    if (!guests) {
+      window.location.reload();
+      return;
+    }
+
+    if (guests.querySelector('tr[data-id="' + guest.id + '"]')) {
+      return;
+    }
+
+    var row = document.createElement("tr");
+    row.dataset.id = guest.id;
+
+    var message = document.createElement("td");
+    message.className = "whitespace-nowrap py-4 pl-4 pr-3 text-sm font-medium text-gray-300 sm:pl-0";
+
+    var author = document.createElement("div");
+    author.className = "flex flex-row items-center gap-2";
+
+    var icon = document.createElement("img");
+    icon.src = "/guests/" + encodeURIComponent(guest.id) + "/identicon.svg";
+    icon.alt = "";
+    icon.width = 20;
+    icon.height = 20;
+    icon.className = "rounded-sm";
+
+    var name = document.createElement(guest.website ? "a" : "span");
+    name.className = "text-xs font-semibold text-gray-400";
+    name.textContent = guest.name || "Anonymous";
+    if (guest.website) {
+      name.href = guest.website;
+      name.rel = "nofollow ugc noopener";
+    }
+
+    author.appendChild(icon);
+    author.appendChild(name);
+    message.appendChild(author);
+    message.appendChild(document.createTextNode(guest.message));
+
+    var reply = document.createElement("a");
+    reply.href = "/guests/" + encodeURIComponent(guest.id);
+    reply.className = "ml-2 text-xs text-gray-400 hover:text-white";
+    reply.textContent = "Reply";
+    message.appendChild(document.createTextNode(" "));
+    message.appendChild(reply);
+
+    var timestamp = document.createElement("td");
+    timestamp.className = "whitespace-nowrap px-3 py-4 text-sm text-gray-400";
+    timestamp.textContent = guest.timestamp;
+
+    var react = document.createElement("td");
+    react.className = "whitespace-nowrap px-3 py-4 text-sm text-gray-400";
+    if (reactions) {
+      var form = reactions.content.firstElementChild.cloneNode(true);
+      form.action = "/guests/" + encodeURIComponent(guest.id) + "/reactions";
+      react.appendChild(form);
+    }
+
+    row.appendChild(message);
+    row.appendChild(timestamp);
+    row.appendChild(react);
+    guests.insertBefore(row, guests.firstChild);
+
+    if (total) {
+      total.textContent = parseInt(total.textContent, 10) + 1;
+    }
+  });
+})();
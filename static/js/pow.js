@@ -0,0 +1,139 @@
+// Solves the proof of work challenge on the page while the visitor writes
+// their message, so it is usually done by the time they post. A solution
+// is a counter that, appended to the challenge, hashes to enough leading
+// zero bits. Crypto.subtle would be faster per hash, but it is async and
+// missing on plain http, so sha-256 is done by hand.
+(function () {
+  var challenge = document.body.dataset.pow;
+  var difficulty = parseInt(document.body.dataset.powDifficulty, 10);
+  var forms = document.querySelectorAll("form[data-pow]");
+  if (!challenge || !forms.length) {
+    return;
+  }
+
+  var K = [
+    0x428a2f98, 0x71374491, 0xb5c0fbcf, 0xe9b5dba5, 0x3956c25b, 0x59f111f1, 0x923f82a4, 0xab1c5ed5,
+    0xd807aa98, 0x12835b01, 0x243185be, 0x550c7dc3, 0x72be5d74, 0x80deb1fe, 0x9bdc06a7, 0xc19bf174,
+    0xe49b69c1, 0xefbe4786, 0x0fc19dc6, 0x240ca1cc, 0x2de92c6f, 0x4a7484aa, 0x5cb0a9dc, 0x76f988da,
+    0x983e5152, 0xa831c66d, 0xb00327c8, 0xbf597fc7, 0xc6e00bf3, 0xd5a79147, 0x06ca6351, 0x14292967,
+    0x27b70a85, 0x2e1b2138, 0x4d2c6dfc, 0x53380d13, 0x650a7354, 0x766a0abb, 0x81c2c92e, 0x92722c85,
+    0xa2bfe8a1, 0xa81a664b, 0xc24b8b70, 0xc76c51a3, 0xd192e819, 0xd6990624, 0xf40e3585, 0x106aa070,
+    0x19a4c116, 0x1e376c08, 0x2748774c, 0x34b0bcb5, 0x391c0cb3, 0x4ed8aa4a, 0x5b9cca4f, 0x682e6ff3,
+    0x748f82ee, 0x78a5636f, 0x84c87814, 0x8cc70208, 0x90befffa, 0xa4506ceb, 0xbef9a3f7, 0xc67178f2
+  ];
+  var H = [
+    0x6a09e667, 0xbb67ae85, 0x3c6ef372, 0xa54ff53a, 0x510e527f, 0x9b05688c, 0x1f83d9ab, 0x5be0cd19
+  ];
+  var w = new Array(64);
+
+  // Returns the sha-256 of an ascii string as eight 32-bit words.
+  function sha256(s) {
+    var n = s.length;
+    var len = (((n + 8) >> 6) + 1) * 16;
+    var words = new Array(len);
+    var i, t;
+
+    for (i = 0; i < len; i++) {
+      words[i] = 0;
+    }
+    for (i = 0; i < n; i++) {
+      words[i >> 2] |= s.charCodeAt(i) << (24 - (i % 4) * 8);
+    }
+    words[n >> 2] |= 0x80 << (24 - (n % 4) * 8);
+    words[len - 1] = n * 8;
+
+    var h = H.slice();
+    for (var block = 0; block < len; block += 16) {
+      for (t = 0; t < 64; t++) {
+        if (t < 16) {
+          w[t] = words[block + t];
+        } else {
+          var x = w[t - 15];
+          var y = w[t - 2];
+          var s0 = ((x >>> 7) | (x << 25)) ^ ((x >>> 18) | (x << 14)) ^ (x >>> 3);
+          var s1 = ((y >>> 17) | (y << 15)) ^ ((y >>> 19) | (y << 13)) ^ (y >>> 10);
+          w[t] = (w[t - 16] + s0 + w[t - 7] + s1) | 0;
+        }
+      }
+
+      var a = h[0], b = h[1], c = h[2], d = h[3], e = h[4], f = h[5], g = h[6], k = h[7];
+      for (t = 0; t < 64; t++) {
+        var S1 = ((e >>> 6) | (e << 26)) ^ ((e >>> 11) | (e << 21)) ^ ((e >>> 25) | (e << 7));
+        var t1 = (k + S1 + ((e & f) ^ (~e & g)) + K[t] + w[t]) | 0;
+        var S0 = ((a >>> 2) | (a << 30)) ^ ((a >>> 13) | (a << 19)) ^ ((a >>> 22) | (a << 10));
+        var t2 = (S0 + ((a & b) ^ (a & c) ^ (b & c))) | 0;
+        k = g; g = f; f = e; e = (d + t1) | 0;
+        d = c; c = b; b = a; a = (t1 + t2) | 0;
+      }
+
+      h[0] = (h[0] + a) | 0; h[1] = (h[1] + b) | 0; h[2] = (h[2] + c) | 0; h[3] = (h[3] + d) | 0;
+      h[4] = (h[4] + e) | 0; h[5] = (h[5] + f) | 0; h[6] = (h[6] + g) | 0; h[7] = (h[7] + k) | 0;
+    }
+
+    return h;
+  }
+
+  function leadingZeros(h) {
+    var zeros = 0;
+    for (var i = 0; i < h.length; i++) {
+      if (h[i] !== 0) {
+        return zeros + Math.clz32(h[i]);
+      }
+      zeros += 32;
+    }
+    return zeros;
+  }
+
+  var solution = null;
+  var waiting = null;
+
+  function field(form, name, value) {
+    var input = document.createElement("input");
+    input.type = "hidden";
+    input.name = name;
+    input.value = value;
+    form.appendChild(input);
+    return input;
+  }
+
+  var solutions = [];
+  forms.forEach(function (form) {
+    field(form, "pow_challenge", challenge);
+    solutions.push(field(form, "pow_solution", ""));
+
+    // Posting before the work is done waits for it to finish.
+    form.addEventListener("submit", function (event) {
+      if (solution !== null) {
+        return;
+      }
+
+      event.preventDefault();
+      waiting = form;
+      var button = form.querySelector("button[type=submit]");
+      if (button) {
+        button.disabled = true;
+        button.textContent = "Checking…";
+      }
+    });
+  });
+
+  // Work in slices so the page stays responsive.
+  var counter = 0;
+  function work() {
+    for (var end = counter + 5000; counter < end; counter++) {
+      if (leadingZeros(sha256(challenge + ":" + counter)) >= difficulty) {
+        solution = String(counter);
+        solutions.forEach(function (input) {
+          input.value = solution;
+        });
+        if (waiting) {
+          waiting.submit();
+        }
+        return;
+      }
+    }
+    setTimeout(work, 0);
+  }
+
+  work();
+})();